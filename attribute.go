@@ -0,0 +1,163 @@
+package fieldmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Attribute is a set of properties of a field, declared with the fieldmap struct tag,
+// e.g. `fieldmap:"readonly,deprecated"`.
+// Attributes of a struct field are inherited by all of its descendants.
+type Attribute uint32
+
+const (
+	// AttributeReadonly for fields that can not be set by clients
+	AttributeReadonly Attribute = 1 << iota
+	// AttributeRequired for fields that must be set when creating
+	AttributeRequired
+	// AttributeImmutable for fields that can be set when creating but can not be updated
+	AttributeImmutable
+	// AttributeDeprecated for fields that should no longer be used
+	AttributeDeprecated
+)
+
+var attributeNames = []struct {
+	attr Attribute
+	name string
+}{
+	{attr: AttributeReadonly, name: "readonly"},
+	{attr: AttributeRequired, name: "required"},
+	{attr: AttributeImmutable, name: "immutable"},
+	{attr: AttributeDeprecated, name: "deprecated"},
+}
+
+func parseAttribute(name string) (Attribute, bool) {
+	for _, entry := range attributeNames {
+		if entry.name == name {
+			return entry.attr, true
+		}
+	}
+	return 0, false
+}
+
+// Names returns the names of attributes in the set
+func (a Attribute) Names() []string {
+	var result []string
+	for _, entry := range attributeNames {
+		if a&entry.attr != 0 {
+			result = append(result, entry.name)
+		}
+	}
+	return result
+}
+
+// String ...
+func (a Attribute) String() string {
+	return strings.Join(a.Names(), ",")
+}
+
+// HasAttribute checks whether the field has all the attributes in attr
+func (f *FieldMap[F, T]) HasAttribute(field F, attr Attribute) bool {
+	return f.attributes[f.indexOf(field)]&attr == attr
+}
+
+// GetAttributes returns the attribute set of a field
+func (f *FieldMap[F, T]) GetAttributes(field F) Attribute {
+	return f.attributes[f.indexOf(field)]
+}
+
+// FieldsWithAttribute returns the fields having all the attributes in attr, in ordinal order
+func (f *FieldMap[F, T]) FieldsWithAttribute(attr Attribute) []F {
+	var result []F
+	for _, field := range f.fields {
+		if f.HasAttribute(field, attr) {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+// MaskPolicy specifies how ValidateMask checks field attributes
+type MaskPolicy int
+
+const (
+	// MaskPolicyCreate rejects readonly fields and requires all required fields
+	MaskPolicyCreate MaskPolicy = iota + 1
+	// MaskPolicyUpdate rejects readonly and immutable fields
+	MaskPolicyUpdate
+)
+
+// MaskError is returned by ValidateMask
+type MaskError struct {
+	FullFieldName string
+	Attribute     Attribute
+}
+
+func (e *MaskError) Error() string {
+	if e.Attribute == AttributeRequired {
+		return fmt.Sprintf("missing required field %q", e.FullFieldName)
+	}
+	return fmt.Sprintf("field %q is %s", e.FullFieldName, e.Attribute)
+}
+
+// ValidateMask checks a list of fields to be set by clients (an update mask) against the field attributes.
+// A struct root in the mask means all of its descendants are set.
+func (f *FieldMap[F, T]) ValidateMask(fields []F, policy MaskPolicy) error {
+	rejected := AttributeReadonly
+	if policy == MaskPolicyUpdate {
+		rejected |= AttributeImmutable
+	}
+
+	inMask := map[F]emptyStruct{}
+	for _, field := range fields {
+		inMask[field] = emptyStruct{}
+
+		if err := f.checkRejectedAttributes(field, rejected); err != nil {
+			return err
+		}
+	}
+
+	if policy != MaskPolicyCreate {
+		return nil
+	}
+
+	for _, field := range f.FieldsWithAttribute(AttributeRequired) {
+		if !f.isCoveredByMask(field, inMask) {
+			return &MaskError{
+				FullFieldName: f.GetFullFieldName(field),
+				Attribute:     AttributeRequired,
+			}
+		}
+	}
+	return nil
+}
+
+func (f *FieldMap[F, T]) checkRejectedAttributes(field F, rejected Attribute) error {
+	attrs := f.GetAttributes(field) & rejected
+	if attrs != 0 {
+		for _, entry := range attributeNames {
+			if attrs&entry.attr != 0 {
+				return &MaskError{
+					FullFieldName: f.GetFullFieldName(field),
+					Attribute:     entry.attr,
+				}
+			}
+		}
+	}
+
+	for _, child := range f.ChildrenOf(field) {
+		if err := f.checkRejectedAttributes(child, rejected); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FieldMap[F, T]) isCoveredByMask(field F, inMask map[F]emptyStruct) bool {
+	for _, ancestor := range f.AncestorOf(field) {
+		if _, ok := inMask[ancestor]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package fieldmap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type sellerWithAttrs struct {
+	Root field
+
+	ID   field `fieldmap:"immutable"`
+	Name field `fieldmap:"required"`
+}
+
+type productWithAttrs struct {
+	Root field
+
+	ID       field           `fieldmap:"readonly"`
+	Sku      field           `fieldmap:"required,immutable"`
+	Name     field           `fieldmap:"required"`
+	OldName  field           `fieldmap:"deprecated"`
+	Seller   sellerWithAttrs `fieldmap:"deprecated"`
+	Stats    sellerData      `fieldmap:"readonly"`
+	ImageURL field
+}
+
+func (d productWithAttrs) GetRoot() field { return d.Root }
+
+func TestAttribute_String(t *testing.T) {
+	assert.Equal(t, "", Attribute(0).String())
+	assert.Equal(t, "readonly", AttributeReadonly.String())
+	assert.Equal(t, "required,deprecated", (AttributeDeprecated | AttributeRequired).String())
+}
+
+func TestFieldMap__Attributes(t *testing.T) {
+	fm := New[field, productWithAttrs]()
+
+	p := fm.GetMapping()
+
+	assert.Equal(t, true, fm.HasAttribute(p.ID, AttributeReadonly))
+	assert.Equal(t, false, fm.HasAttribute(p.ID, AttributeRequired))
+	assert.Equal(t, true, fm.HasAttribute(p.Sku, AttributeRequired|AttributeImmutable))
+	assert.Equal(t, false, fm.HasAttribute(p.ImageURL, AttributeDeprecated))
+
+	// inherited
+	assert.Equal(t, true, fm.HasAttribute(p.Seller.Root, AttributeDeprecated))
+	assert.Equal(t, AttributeDeprecated|AttributeImmutable, fm.GetAttributes(p.Seller.ID))
+	assert.Equal(t, true, fm.HasAttribute(p.Stats.Attr.Code, AttributeReadonly))
+
+	assert.Equal(t,
+		[]field{p.OldName, p.Seller.Root, p.Seller.ID, p.Seller.Name},
+		fm.FieldsWithAttribute(AttributeDeprecated),
+	)
+	assert.Equal(t,
+		[]field{p.Sku, p.Name, p.Seller.Name},
+		fm.FieldsWithAttribute(AttributeRequired),
+	)
+}
+
+func TestFieldMap__ValidateMask(t *testing.T) {
+	fm := New[field, productWithAttrs]()
+
+	p := fm.GetMapping()
+
+	t.Run("update ok", func(t *testing.T) {
+		err := fm.ValidateMask([]field{p.Name, p.Seller.Name, p.ImageURL}, MaskPolicyUpdate)
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("update readonly", func(t *testing.T) {
+		err := fm.ValidateMask([]field{p.Name, p.Stats.Attr.Code}, MaskPolicyUpdate)
+		assert.Equal(t, &MaskError{
+			FullFieldName: "Stats.Attr.Code",
+			Attribute:     AttributeReadonly,
+		}, err)
+		assert.Equal(t, `field "Stats.Attr.Code" is readonly`, err.Error())
+	})
+
+	t.Run("update immutable", func(t *testing.T) {
+		err := fm.ValidateMask([]field{p.Sku}, MaskPolicyUpdate)
+		assert.Equal(t, `field "Sku" is immutable`, err.Error())
+	})
+
+	t.Run("update struct root containing immutable field", func(t *testing.T) {
+		err := fm.ValidateMask([]field{p.Seller.Root}, MaskPolicyUpdate)
+		assert.Equal(t, `field "Seller.ID" is immutable`, err.Error())
+	})
+
+	t.Run("create ok", func(t *testing.T) {
+		err := fm.ValidateMask([]field{p.Sku, p.Name, p.Seller.Root}, MaskPolicyCreate)
+		assert.Equal(t, nil, err)
+	})
+
+	t.Run("create missing required", func(t *testing.T) {
+		err := fm.ValidateMask([]field{p.Sku, p.Name, p.Seller.ID}, MaskPolicyCreate)
+		assert.Equal(t, `missing required field "Seller.Name"`, err.Error())
+	})
+
+	t.Run("create readonly", func(t *testing.T) {
+		err := fm.ValidateMask([]field{p.ID, p.Sku, p.Name, p.Seller.Name}, MaskPolicyCreate)
+		assert.Equal(t, `field "ID" is readonly`, err.Error())
+	})
+}
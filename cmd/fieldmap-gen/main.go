@@ -0,0 +1,58 @@
+// Command fieldmap-gen generates mapping structs from data structs annotated with //fieldmap:generate.
+//
+// Usage with go generate:
+//
+//	//go:generate fieldmap-gen -type field -tags json,db
+//
+//	//fieldmap:generate
+//	type Product struct {
+//		Sku    string `json:"sku"`
+//		Seller Seller `json:"seller"`
+//	}
+//
+// generates ProductMapping and SellerMapping, each with a Root field and a GetRoot method.
+// Structs declared in the same package are nested mapping structs, maps with string keys are map nodes,
+// other fields are leaves, use `fieldmap:"leaf"` for structs that should be leaves.
+//
+// With -tables, it also generates ProductMappingTables, for constructing the FieldMap without reflection:
+//
+//	fm := fieldmap.NewFromTables(ProductMappingTables)
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/QuangTung97/fieldmap/internal/gen"
+)
+
+func main() {
+	fieldType := flag.String("type", "", "name of the field type F, required")
+	tags := flag.String("tags", "", "comma-separated list of struct tags to copy")
+	output := flag.String("output", "fieldmap_gen.go", "name of the output file")
+	dir := flag.String("dir", ".", "directory of the package")
+	tables := flag.Bool("tables", false, "also generate fieldmap.Tables for fieldmap.NewFromTables")
+	flag.Parse()
+
+	conf := gen.Config{
+		FieldType: *fieldType,
+		Tables:    *tables,
+	}
+	if len(*tags) > 0 {
+		conf.Tags = strings.Split(*tags, ",")
+	}
+
+	content, err := gen.GenerateDir(*dir, conf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fieldmap-gen:", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(filepath.Join(*dir, *output), content, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "fieldmap-gen:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,492 @@
+package fieldmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat is the format of mapping configurations for LoadMapper
+type ConfigFormat int
+
+const (
+	// ConfigYAML ...
+	ConfigYAML ConfigFormat = iota + 1
+	// ConfigJSON ...
+	ConfigJSON
+)
+
+// ConfigError is returned by LoadMapper for invalid configurations
+type ConfigError struct {
+	// File is empty if not specified by WithConfigFileName
+	File string
+	// Line is zero if unknown
+	Line int
+
+	Message string
+}
+
+// Error ...
+func (e *ConfigError) Error() string {
+	var prefix string
+	switch {
+	case len(e.File) > 0 && e.Line > 0:
+		prefix = fmt.Sprintf("%s:%d: ", e.File, e.Line)
+	case len(e.File) > 0:
+		prefix = e.File + ": "
+	case e.Line > 0:
+		prefix = fmt.Sprintf("line %d: ", e.Line)
+	}
+	return prefix + e.Message
+}
+
+type loadOptions struct {
+	fileName string
+}
+
+// LoadOption ...
+type LoadOption func(opts *loadOptions)
+
+// WithConfigFileName specifies the file name in errors of LoadMapper
+func WithConfigFileName(name string) LoadOption {
+	return func(opts *loadOptions) {
+		opts.fileName = name
+	}
+}
+
+// LoadMapper creates a Mapper from rules in a YAML or JSON configuration, e.g.
+//
+//	tag: json
+//	rules:
+//	  - seller.attr.code -> [detail.body, searchText]
+//	  - from: sku
+//	    to: info.sku
+//	mounts:
+//	  - source: seller
+//	    dest: detail
+//	    rules:
+//	      - name -> body
+//
+// Paths are full field names, or full struct tags if tag is specified.
+// Paths of rules in a mount block are relative to the source and dest of the block.
+// The path "." is the root field at the top level, and the block's field itself in a mount block,
+// empty paths are invalid.
+// Mount blocks can be nested.
+func LoadMapper[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]](
+	source *FieldMap[F1, T1], dest *FieldMap[F2, T2],
+	r io.Reader, format ConfigFormat, options ...LoadOption,
+) (*Mapper[F1, T1, F2, T2], error) {
+	var opts loadOptions
+	for _, fn := range options {
+		fn(&opts)
+	}
+
+	l := &configLoader[F1, T1, F2, T2]{
+		source:   source,
+		dest:     dest,
+		fileName: opts.fileName,
+	}
+
+	root, err := l.parse(r, format)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.loadRoot(root); err != nil {
+		return nil, err
+	}
+
+	m, err := newMapper(source, dest, l.mappings)
+	if err != nil {
+		var mapperErr *mapperError
+		if errors.As(err, &mapperErr) {
+			return nil, l.errorf(l.lines[mapperErr.index], "%s", mapperErr.message)
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+type configLoader[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]] struct {
+	source *FieldMap[F1, T1]
+	dest   *FieldMap[F2, T2]
+
+	fileName string
+	tag      string
+
+	mappings []MappingData[F1, F2]
+	// lines of mappings
+	lines []int
+}
+
+func (l *configLoader[F1, T1, F2, T2]) errorf(line int, format string, args ...any) error {
+	return &ConfigError{
+		File:    l.fileName,
+		Line:    line,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (l *configLoader[F1, T1, F2, T2]) parse(r io.Reader, format ConfigFormat) (*yaml.Node, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case ConfigYAML:
+	case ConfigJSON:
+		// JSON is parsed as YAML for line numbers, but must be valid JSON
+		var value any
+		if err := json.Unmarshal(data, &value); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+				return nil, l.errorf(line, "%s", syntaxErr.Error())
+			}
+			return nil, l.errorf(0, "%s", err.Error())
+		}
+	default:
+		return nil, fmt.Errorf("invalid config format %d", format)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, l.errorf(0, "%s", err.Error())
+	}
+	if len(doc.Content) == 0 {
+		return nil, l.errorf(0, "empty config")
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, l.errorf(root.Line, "config must be a mapping")
+	}
+	return root, nil
+}
+
+// forEachKey calls fn with each key and value of a mapping node, keys must be in the list of keys
+func (l *configLoader[F1, T1, F2, T2]) forEachKey(
+	node *yaml.Node, keys []string, fn func(key string, value *yaml.Node) error,
+) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+
+		found := false
+		for _, k := range keys {
+			if k == key.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return l.errorf(key.Line, "unknown key %q", key.Value)
+		}
+
+		if err := fn(key.Value, node.Content[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *configLoader[F1, T1, F2, T2]) scalar(node *yaml.Node, name string) (string, error) {
+	if node.Kind != yaml.ScalarNode {
+		return "", l.errorf(node.Line, "%s must be a string", name)
+	}
+	return node.Value, nil
+}
+
+func (l *configLoader[F1, T1, F2, T2]) sequence(node *yaml.Node, name string) ([]*yaml.Node, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, l.errorf(node.Line, "%s must be a list", name)
+	}
+	return node.Content, nil
+}
+
+func (l *configLoader[F1, T1, F2, T2]) checkTag(tags []string, line int) error {
+	for _, tag := range tags {
+		if tag == l.tag {
+			return nil
+		}
+	}
+	return l.errorf(line, "struct tag %q is not configured", l.tag)
+}
+
+func (l *configLoader[F1, T1, F2, T2]) loadRoot(root *yaml.Node) error {
+	// the tag must be known before resolving paths
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "tag" {
+			continue
+		}
+		tagNode := root.Content[i+1]
+		tag, err := l.scalar(tagNode, "tag")
+		if err != nil {
+			return err
+		}
+		l.tag = tag
+		if err := l.checkTag(l.source.options.structTags, tagNode.Line); err != nil {
+			return err
+		}
+		if err := l.checkTag(l.dest.options.structTags, tagNode.Line); err != nil {
+			return err
+		}
+	}
+
+	return l.forEachKey(root, []string{"tag", "rules", "mounts"}, func(key string, value *yaml.Node) error {
+		switch key {
+		case "rules":
+			return l.loadRules(value, "", "", false)
+		case "mounts":
+			return l.loadMounts(value, "", "")
+		default:
+			return nil
+		}
+	})
+}
+
+func (l *configLoader[F1, T1, F2, T2]) loadMounts(node *yaml.Node, sourcePrefix string, destPrefix string) error {
+	items, err := l.sequence(node, "mounts")
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.Kind != yaml.MappingNode {
+			return l.errorf(item.Line, "mount must be a mapping")
+		}
+
+		var sourcePath, destPath string
+		var rules, mounts *yaml.Node
+
+		err := l.forEachKey(item, []string{"source", "dest", "rules", "mounts"}, func(key string, value *yaml.Node) error {
+			var err error
+			switch key {
+			case "source":
+				sourcePath, err = l.scalar(value, "source")
+			case "dest":
+				destPath, err = l.scalar(value, "dest")
+			case "rules":
+				rules = value
+			case "mounts":
+				mounts = value
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(sourcePath) == 0 {
+			return l.errorf(item.Line, "missing source of mount")
+		}
+		if len(destPath) == 0 {
+			return l.errorf(item.Line, "missing dest of mount")
+		}
+
+		sourcePrefix := joinConfigPath(sourcePrefix, sourcePath)
+		if _, err := l.findSource(sourcePrefix, item.Line); err != nil {
+			return err
+		}
+		destPrefix := joinConfigPath(destPrefix, destPath)
+		if _, err := l.findDest(destPrefix, item.Line); err != nil {
+			return err
+		}
+
+		if rules != nil {
+			if err := l.loadRules(rules, sourcePrefix, destPrefix, true); err != nil {
+				return err
+			}
+		}
+		if mounts != nil {
+			if err := l.loadMounts(mounts, sourcePrefix, destPrefix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rootConfigPath is the path of the root field, or of the block's field in a mount block
+const rootConfigPath = "."
+
+func joinConfigPath(prefix string, path string) string {
+	if path == rootConfigPath {
+		path = ""
+	}
+	if len(prefix) == 0 {
+		return path
+	}
+	if len(path) == 0 {
+		return prefix
+	}
+	return prefix + "." + path
+}
+
+func (l *configLoader[F1, T1, F2, T2]) findSource(path string, line int) (F1, error) {
+	if len(path) == 0 {
+		return l.source.structRoot, nil
+	}
+
+	var field F1
+	var ok bool
+	if len(l.tag) == 0 {
+		field, ok = l.source.FindByFullFieldName(path)
+	} else {
+		field, ok = l.source.FindByFullStructTag(l.tag, path)
+	}
+	if !ok {
+		return field, l.errorf(line, "unknown source field %q", path)
+	}
+	return field, nil
+}
+
+func (l *configLoader[F1, T1, F2, T2]) findDest(path string, line int) (F2, error) {
+	if len(path) == 0 {
+		return l.dest.structRoot, nil
+	}
+
+	var field F2
+	var ok bool
+	if len(l.tag) == 0 {
+		field, ok = l.dest.FindByFullFieldName(path)
+	} else {
+		field, ok = l.dest.FindByFullStructTag(l.tag, path)
+	}
+	if !ok {
+		return field, l.errorf(line, "unknown destination field %q", path)
+	}
+	return field, nil
+}
+
+type configRule struct {
+	from   string
+	toList []string
+	line   int
+}
+
+// parseArrowRule parses rules of the form "from -> to" or "from -> [to1, to2]"
+func parseArrowRule(s string) (configRule, bool) {
+	parts := strings.SplitN(s, "->", 2)
+	if len(parts) != 2 {
+		return configRule{}, false
+	}
+
+	rule := configRule{from: strings.TrimSpace(parts[0])}
+
+	to := strings.TrimSpace(parts[1])
+	if strings.HasPrefix(to, "[") && strings.HasSuffix(to, "]") {
+		to = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(to, "["), "]"))
+		if len(to) == 0 {
+			return rule, true
+		}
+		for _, path := range strings.Split(to, ",") {
+			rule.toList = append(rule.toList, strings.TrimSpace(path))
+		}
+	} else if len(to) > 0 {
+		rule.toList = []string{to}
+	}
+	return rule, true
+}
+
+func (l *configLoader[F1, T1, F2, T2]) parseRule(item *yaml.Node) (configRule, error) {
+	if item.Kind == yaml.ScalarNode {
+		rule, ok := parseArrowRule(item.Value)
+		if !ok {
+			return rule, l.errorf(item.Line, "invalid rule %q", item.Value)
+		}
+		rule.line = item.Line
+		return rule, nil
+	}
+
+	if item.Kind != yaml.MappingNode {
+		return configRule{}, l.errorf(item.Line, "rule must be a string or a mapping")
+	}
+
+	rule := configRule{line: item.Line}
+	hasFrom := false
+	err := l.forEachKey(item, []string{"from", "to"}, func(key string, value *yaml.Node) error {
+		if key == "from" {
+			hasFrom = true
+			var err error
+			rule.from, err = l.scalar(value, "from")
+			return err
+		}
+
+		if value.Kind == yaml.ScalarNode {
+			rule.toList = []string{value.Value}
+			return nil
+		}
+		toList, err := l.sequence(value, "to")
+		if err != nil {
+			return err
+		}
+		for _, to := range toList {
+			path, err := l.scalar(to, "to")
+			if err != nil {
+				return err
+			}
+			rule.toList = append(rule.toList, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return rule, err
+	}
+
+	if !hasFrom {
+		return rule, l.errorf(item.Line, "missing source field of rule")
+	}
+	return rule, nil
+}
+
+func (l *configLoader[F1, T1, F2, T2]) loadRules(
+	node *yaml.Node, sourcePrefix string, destPrefix string, inherited bool,
+) error {
+	items, err := l.sequence(node, "rules")
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		rule, err := l.parseRule(item)
+		if err != nil {
+			return err
+		}
+		if len(rule.toList) == 0 {
+			return l.errorf(rule.line, "missing destination fields")
+		}
+		if len(rule.from) == 0 {
+			return l.errorf(rule.line, "empty source field, use %q for the root field", rootConfigPath)
+		}
+		for _, path := range rule.toList {
+			if len(path) == 0 {
+				return l.errorf(rule.line, "empty destination field, use %q for the root field", rootConfigPath)
+			}
+		}
+
+		from, err := l.findSource(joinConfigPath(sourcePrefix, rule.from), rule.line)
+		if err != nil {
+			return err
+		}
+
+		toList := make([]F2, 0, len(rule.toList))
+		for _, path := range rule.toList {
+			to, err := l.findDest(joinConfigPath(destPrefix, path), rule.line)
+			if err != nil {
+				return err
+			}
+			toList = append(toList, to)
+		}
+
+		m := NewMapping(from, toList...)
+		m.inherited = inherited
+		l.mappings = append(l.mappings, m)
+		l.lines = append(l.lines, rule.line)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package fieldmap
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type configSellerAttr struct {
+	Root sourceField
+	Code sourceField `json:"code"`
+}
+
+type configSeller struct {
+	Root sourceField
+	ID   sourceField      `json:"id"`
+	Name sourceField      `json:"name"`
+	Attr configSellerAttr `json:"attr"`
+}
+
+type configSource struct {
+	Root   sourceField
+	Sku    sourceField  `json:"sku"`
+	Name   sourceField  `json:"name"`
+	Seller configSeller `json:"seller"`
+}
+
+func (d configSource) GetRoot() sourceField { return d.Root }
+
+type configDetail struct {
+	Root destField
+	Body destField `json:"body"`
+	Logo destField `json:"logo"`
+}
+
+type configDest struct {
+	Root       destField
+	Info       destField    `json:"info"`
+	Detail     configDetail `json:"detail"`
+	SearchText destField    `json:"searchText"`
+}
+
+func (d configDest) GetRoot() destField { return d.Root }
+
+func newConfigFieldMaps() (*FieldMap[sourceField, configSource], *FieldMap[destField, configDest]) {
+	return New[sourceField, configSource](WithStructTags("json")), New[destField, configDest](WithStructTags("json"))
+}
+
+func TestLoadMapper(t *testing.T) {
+	t.Run("yaml with struct tags", func(t *testing.T) {
+		sourceFm, destFm := newConfigFieldMaps()
+		source := sourceFm.GetMapping()
+		dest := destFm.GetMapping()
+
+		m, err := LoadMapper(sourceFm, destFm, strings.NewReader(`
+tag: json
+rules:
+  - seller.attr.code -> [detail.body, searchText]
+  - from: sku
+    to: info
+  - from: name
+    to: [info, searchText]
+mounts:
+  - source: seller
+    dest: detail
+    rules:
+      - id -> logo
+      - ". -> body"
+`), ConfigYAML)
+		assert.Equal(t, nil, err)
+
+		assert.Equal(t, []destField{dest.Detail.Body, dest.SearchText},
+			m.FindMappedFields([]sourceField{source.Seller.Attr.Code}))
+		assert.Equal(t, []destField{dest.Info}, m.FindMappedFields([]sourceField{source.Sku}))
+		assert.Equal(t, []destField{dest.Info, dest.SearchText}, m.FindMappedFields([]sourceField{source.Name}))
+		assert.Equal(t, []destField{dest.Detail.Logo}, m.FindMappedFields([]sourceField{source.Seller.ID}))
+		assert.Equal(t, []destField{dest.Detail.Body}, m.FindMappedFields([]sourceField{source.Seller.Name}))
+
+		var buf strings.Builder
+		assert.Equal(t, nil, m.WriteMarkdownTable(&buf))
+		assert.Equal(t, `| # | Source | Destinations | Alternative | Inherited |
+|---|--------|--------------|-------------|-----------|
+| 1 | Seller.Attr.Code | Detail.Body AND SearchText |  |  |
+| 2 | Sku | Info |  |  |
+| 3 | Name | Info AND SearchText |  |  |
+| 4 | Seller.ID | Detail.Logo |  | yes |
+| 5 | Seller | Detail.Body |  | yes |
+`, buf.String())
+	})
+
+	t.Run("json with full field names and nested mounts", func(t *testing.T) {
+		sourceFm, destFm := newConfigFieldMaps()
+		source := sourceFm.GetMapping()
+		dest := destFm.GetMapping()
+
+		m, err := LoadMapper(sourceFm, destFm, strings.NewReader(`{
+  "rules": ["Sku -> Info"],
+  "mounts": [
+    {
+      "source": "Seller",
+      "dest": "Detail",
+      "mounts": [{"source": "Attr", "dest": "Logo", "rules": [{"from": "Code", "to": "."}]}]
+    }
+  ]
+}`), ConfigJSON)
+		assert.Equal(t, nil, err)
+
+		assert.Equal(t, []destField{dest.Info}, m.FindMappedFields([]sourceField{source.Sku}))
+		assert.Equal(t, []destField{dest.Detail.Logo}, m.FindMappedFields([]sourceField{source.Seller.Attr.Code}))
+	})
+
+	t.Run("root paths", func(t *testing.T) {
+		sourceFm, destFm := newConfigFieldMaps()
+		source := sourceFm.GetMapping()
+		dest := destFm.GetMapping()
+
+		m, err := LoadMapper(sourceFm, destFm, strings.NewReader(`
+rules:
+  - ". -> SearchText"
+  - from: Sku
+    to: .
+mounts:
+  - source: Seller
+    dest: .
+    rules:
+      - . -> Info
+      - Attr -> .
+`), ConfigYAML)
+		assert.Equal(t, nil, err)
+
+		assert.Equal(t, []destField{dest.SearchText}, m.FindMappedFields([]sourceField{source.Name}))
+		assert.Equal(t, []destField{dest.Root}, m.FindMappedFields([]sourceField{source.Sku}))
+		assert.Equal(t, []destField{dest.Info}, m.FindMappedFields([]sourceField{source.Seller.ID}))
+		assert.Equal(t, []destField{dest.Root}, m.FindMappedFields([]sourceField{source.Seller.Attr.Code}))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		sourceFm, destFm := newConfigFieldMaps()
+
+		load := func(format ConfigFormat, config string) error {
+			_, err := LoadMapper(sourceFm, destFm, strings.NewReader(config), format,
+				WithConfigFileName("mapping.yaml"))
+			return err
+		}
+
+		assert.Equal(t, &ConfigError{
+			File:    "mapping.yaml",
+			Line:    4,
+			Message: `unknown source field "seller.attr.unknown"`,
+		}, load(ConfigYAML, "tag: json\nrules:\n  - sku -> info\n  - seller.attr.unknown -> info\n"))
+
+		assert.Equal(t,
+			`mapping.yaml:3: unknown destination field "Detail.Unknown"`,
+			load(ConfigYAML, "rules:\n  - Sku -> Info\n  - Sku -> [SearchText, Detail.Unknown]\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:3: duplicated destination field "Info" for source field "Sku"`,
+			load(ConfigYAML, "rules:\n  - Sku -> Info\n  - from: Sku\n    to: Info\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:1: struct tag "db" is not configured`,
+			load(ConfigYAML, "tag: db\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:2: unknown key "rule"`,
+			load(ConfigYAML, "tag: json\nrule: []\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:2: invalid rule "Sku"`,
+			load(ConfigYAML, "rules:\n  - Sku\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:2: missing source field of rule`,
+			load(ConfigYAML, "rules:\n  - to: Info\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:2: missing destination fields`,
+			load(ConfigYAML, "rules:\n  - from: Sku\n    to: []\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:2: missing destination fields`,
+			load(ConfigYAML, "rules:\n  - Sku -> []\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:3: missing destination fields`,
+			load(ConfigYAML, "rules:\n  - Sku -> Info\n  - 'Name -> '\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:2: empty destination field, use "." for the root field`,
+			load(ConfigYAML, "rules:\n  - from: Sku\n    to: ''\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:2: empty destination field, use "." for the root field`,
+			load(ConfigYAML, "rules:\n  - Sku -> [Info, ]\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:4: empty source field, use "." for the root field`,
+			load(ConfigYAML, "mounts:\n  - source: Seller\n    dest: Detail\n    rules: ['-> Body']\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:2: missing dest of mount`,
+			load(ConfigYAML, "mounts:\n  - source: Seller\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:4: unknown source field "Seller.Unknown"`,
+			load(ConfigYAML, "mounts:\n  - source: Seller\n    mounts:\n      - {source: Unknown, dest: Logo}\n    dest: Detail\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:1: rules must be a list`,
+			load(ConfigYAML, "rules: Sku -> Info\n").Error())
+
+		assert.Equal(t,
+			`mapping.yaml:3: invalid character '}' looking for beginning of object key string`,
+			load(ConfigJSON, "{\n  \"rules\": [],\n}").Error())
+
+		assert.Equal(t,
+			`mapping.yaml: empty config`,
+			load(ConfigYAML, "").Error())
+
+		assert.Equal(t, errors.New("invalid config format 3"), load(ConfigFormat(3), ""))
+	})
+}
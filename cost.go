@@ -0,0 +1,130 @@
+package fieldmap
+
+import "fmt"
+
+// SetCost sets the cost of a field, e.g. the cost of a backend call or a recomputation for the field.
+// Fields have zero cost by default. Mappers with this FieldMap as the destination
+// use costs for choosing between alternative rules, see FindMappedFieldsCtx.
+// Should be called before using the Mappers, the same as RegisterKey.
+func (f *FieldMap[F, T]) SetCost(field F, cost int) {
+	if cost < 0 {
+		panic(fmt.Sprintf("negative cost %d of field %q", cost, f.GetFullFieldName(field)))
+	}
+	f.costs[field] = cost
+}
+
+// GetCost returns the cost of the field set by SetCost
+func (f *FieldMap[F, T]) GetCost(field F) int {
+	return f.costs[field]
+}
+
+func (f *FieldMap[F, T]) getCosts() map[F]int {
+	return f.costs
+}
+
+// selectAlternatives chooses one alternative for each source field, candidates[i] are the alternatives
+// of the i-th source field in the order of rules. Returns nil for source fields without alternatives.
+//
+// Source fields with a single alternative are decided first. Then, by greedy set cover,
+// the alternative with the lowest ratio of its additional cost to the number of undecided source fields
+// it covers is selected, until all source fields are decided. Ties are broken by the order of
+// source fields then the order of rules. A source field is covered if all fields of one of its
+// alternatives are selected, that alternative (the first in the order of rules) is used.
+func selectAlternatives[F Field](candidates [][][]F, costs map[F]int) [][]F {
+	chosen := make([][]F, len(candidates))
+	decided := make([]bool, len(candidates))
+	selected := map[F]emptyStruct{}
+
+	remaining := 0
+	for i, alternatives := range candidates {
+		switch len(alternatives) {
+		case 0:
+			decided[i] = true
+		case 1:
+			decided[i] = true
+			chosen[i] = alternatives[0]
+			for _, field := range alternatives[0] {
+				selected[field] = emptyStruct{}
+			}
+		default:
+			remaining++
+		}
+	}
+
+	isSelected := func(field F, extra map[F]emptyStruct) bool {
+		if _, ok := selected[field]; ok {
+			return true
+		}
+		_, ok := extra[field]
+		return ok
+	}
+
+	// findCovered returns the first alternative of the source field that is covered
+	findCovered := func(alternatives [][]F, extra map[F]emptyStruct) ([]F, bool) {
+	alternativeLoop:
+		for _, alternative := range alternatives {
+			for _, field := range alternative {
+				if !isSelected(field, extra) {
+					continue alternativeLoop
+				}
+			}
+			return alternative, true
+		}
+		return nil, false
+	}
+
+	for remaining > 0 {
+		var best []F
+		var bestCost, bestCovered int
+
+		for i, alternatives := range candidates {
+			if decided[i] {
+				continue
+			}
+			for _, alternative := range alternatives {
+				extra := map[F]emptyStruct{}
+				cost := 0
+				for _, field := range alternative {
+					if isSelected(field, extra) {
+						continue
+					}
+					extra[field] = emptyStruct{}
+					cost += costs[field]
+				}
+
+				covered := 0
+				for k := range candidates {
+					if decided[k] {
+						continue
+					}
+					if _, ok := findCovered(candidates[k], extra); ok {
+						covered++
+					}
+				}
+
+				// compare cost / covered with bestCost / bestCovered
+				if best == nil || cost*bestCovered < bestCost*covered {
+					best = alternative
+					bestCost = cost
+					bestCovered = covered
+				}
+			}
+		}
+
+		for _, field := range best {
+			selected[field] = emptyStruct{}
+		}
+		for k := range candidates {
+			if decided[k] {
+				continue
+			}
+			if alternative, ok := findCovered(candidates[k], nil); ok {
+				decided[k] = true
+				chosen[k] = alternative
+				remaining--
+			}
+		}
+	}
+
+	return chosen
+}
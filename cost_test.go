@@ -0,0 +1,161 @@
+package fieldmap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSelectAlternatives(t *testing.T) {
+	t.Run("single alternatives", func(t *testing.T) {
+		result := selectAlternatives([][][]destField{
+			{{1, 2}},
+			nil,
+			{{3}},
+		}, map[destField]int{1: 10})
+		assert.Equal(t, [][]destField{{1, 2}, nil, {3}}, result)
+	})
+
+	t.Run("reuse selected fields", func(t *testing.T) {
+		result := selectAlternatives([][][]destField{
+			{{1}, {2}},
+			{{2}},
+		}, map[destField]int{1: 1, 2: 5})
+		assert.Equal(t, [][]destField{{2}, {2}}, result)
+	})
+
+	t.Run("cheapest alternative", func(t *testing.T) {
+		result := selectAlternatives([][][]destField{
+			{{1, 2}, {3}},
+		}, map[destField]int{1: 1, 2: 1, 3: 3})
+		assert.Equal(t, [][]destField{{1, 2}}, result)
+	})
+
+	t.Run("shared alternative", func(t *testing.T) {
+		result := selectAlternatives([][][]destField{
+			{{1}, {2}},
+			{{1}, {3}},
+		}, map[destField]int{1: 5, 2: 3, 3: 4})
+		assert.Equal(t, [][]destField{{1}, {1}}, result)
+	})
+
+	t.Run("tie break by order", func(t *testing.T) {
+		result := selectAlternatives([][][]destField{
+			{{1}, {2}},
+			{{3}, {4}},
+		}, map[destField]int{1: 2, 2: 2, 3: 2, 4: 2})
+		assert.Equal(t, [][]destField{{1}, {3}}, result)
+
+		result = selectAlternatives([][][]destField{
+			{{1}, {2}},
+			{{2}, {1}},
+		}, map[destField]int{})
+		assert.Equal(t, [][]destField{{1}, {1}}, result)
+	})
+
+	t.Run("covered by a superset", func(t *testing.T) {
+		result := selectAlternatives([][][]destField{
+			{{1, 2}, {4}},
+			{{1}, {5}},
+			{{2}, {6}},
+		}, map[destField]int{1: 2, 2: 2, 4: 3, 5: 3, 6: 3})
+		assert.Equal(t, [][]destField{{1, 2}, {1}, {2}}, result)
+	})
+}
+
+func TestMapping_Cost(t *testing.T) {
+	sourceFm := New[sourceField, sourceDataComplex]()
+	destFm := New[destField, destDataComplex]()
+
+	source := sourceFm.GetMapping()
+	dest := destFm.GetMapping()
+
+	m := NewMapper(
+		sourceFm, destFm,
+		WithSimpleMapping(sourceFm, destFm,
+			NewMapping(source.Sku, dest.Info.Sku),
+			NewMapping(source.Sku, dest.SearchText),
+			NewMapping(source.Name, dest.Info.Name, dest.Detail.Body),
+			NewMapping(source.Name, dest.SearchText),
+			NewMapping(source.Seller.Root, dest.Detail.Root),
+			NewMapping(source.Seller.Name, dest.Detail.Body).When(isTenant("a")),
+			NewMapping(source.Seller.Name, dest.Info.Name),
+		),
+	)
+
+	assert.Equal(t, []destField{dest.Info.Sku, dest.Info.Name, dest.Detail.Body},
+		m.FindMappedFields([]sourceField{source.Sku, source.Name}))
+
+	destFm.SetCost(dest.Info.Sku, 2)
+	destFm.SetCost(dest.Info.Name, 2)
+	destFm.SetCost(dest.SearchText, 3)
+	assert.Equal(t, 2, destFm.GetCost(dest.Info.Name))
+	assert.Equal(t, 0, destFm.GetCost(dest.Detail.Body))
+
+	assert.Equal(t, []destField{dest.Info.Sku}, m.FindMappedFields([]sourceField{source.Sku}))
+	assert.Equal(t, []destField{dest.Info.Name, dest.Detail.Body}, m.FindMappedFields([]sourceField{source.Name}))
+	assert.Equal(t, []destField{dest.SearchText},
+		m.FindMappedFields([]sourceField{source.Sku, source.Name}))
+
+	assert.Equal(t, []destField{dest.Info.Sku, dest.Detail.Body},
+		m.FindMappedFieldsCtx(withTenant("a"), []sourceField{source.Sku, source.Seller.Name}))
+	assert.Equal(t, []destField{dest.Info.Name, dest.Detail.Body},
+		m.FindMappedFieldsCtx(withTenant("a"), []sourceField{source.Name, source.Seller.Name}))
+
+	assert.Equal(t, []destField{dest.Detail.Root},
+		m.FindMappedFields([]sourceField{source.Seller.ID}))
+
+	assert.PanicsWithValue(t, `negative cost -1 of field "Info.Sku"`, func() {
+		destFm.SetCost(dest.Info.Sku, -1)
+	})
+}
+
+func TestMapping_Cost_AnyOf(t *testing.T) {
+	sourceFm := New[sourceField, sourceDataComplex]()
+	destFm := New[destField, destDataComplex]()
+
+	source := sourceFm.GetMapping()
+	dest := destFm.GetMapping()
+
+	m := NewMapper(
+		sourceFm, destFm,
+		WithSimpleMapping(sourceFm, destFm,
+			NewMappingExpr(source.Sku, AnyOf(Dest(dest.Info.Sku), Dest(dest.SearchText))),
+			NewMapping(source.Sku, dest.Detail.Body),
+			NewMapping(source.Name, dest.Detail.Body),
+			NewMapping(source.Name, dest.SearchText),
+		),
+	)
+
+	destFm.SetCost(dest.Info.Sku, 1)
+	destFm.SetCost(dest.SearchText, 10)
+	destFm.SetCost(dest.Detail.Body, 3)
+
+	assert.Equal(t, []destField{dest.Info.Sku}, m.FindMappedFields([]sourceField{source.Sku}))
+	assert.Equal(t, []destField{dest.Info.Sku, dest.Detail.Body},
+		m.FindMappedFields([]sourceField{source.Sku, source.Name}))
+
+	t.Run("cheaper branch is not the first one", func(t *testing.T) {
+		costFm := New[destField, destDataComplex]()
+		m := NewMapper(
+			sourceFm, costFm,
+			WithSimpleMapping(sourceFm, costFm,
+				NewMappingExpr(source.Sku, AnyOf(Dest(dest.SearchText), Dest(dest.Info.Sku))),
+				NewMappingExpr(source.Name, AllOf(
+					Dest(dest.Detail.Body),
+					AnyOf(Dest(dest.SearchText), Dest(dest.Info.Name)),
+				)),
+			),
+		)
+
+		// the first branch is used without costs
+		assert.Equal(t, []destField{dest.SearchText}, m.FindMappedFields([]sourceField{source.Sku}))
+		assert.Equal(t, []destField{dest.Detail.Body, dest.SearchText}, m.FindMappedFields([]sourceField{source.Name}))
+
+		costFm.SetCost(dest.SearchText, 100)
+		costFm.SetCost(dest.Info.Sku, 1)
+		costFm.SetCost(dest.Info.Name, 1)
+
+		assert.Equal(t, []destField{dest.Info.Sku}, m.FindMappedFields([]sourceField{source.Sku}))
+		assert.Equal(t, []destField{dest.Detail.Body, dest.Info.Name}, m.FindMappedFields([]sourceField{source.Name}))
+	})
+}
@@ -0,0 +1,206 @@
+package fieldmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DependencyData is a rule of a DependencyGraph: a derived field is computed from the fields it depends on
+type DependencyData[F Field] struct {
+	field     F
+	dependsOn []F
+}
+
+// NewDependency creates a rule that the field is derived from the fields dependsOn
+func NewDependency[F Field](field F, dependsOn ...F) DependencyData[F] {
+	if len(dependsOn) == 0 {
+		panic("missing dependencies")
+	}
+	return DependencyData[F]{field: field, dependsOn: dependsOn}
+}
+
+// DependencyGraph contains the dependencies between derived fields and other fields of the same FieldMap.
+//
+// A dependency on a field is affected by a change of the field, of its ancestors and of its descendants,
+// e.g. a field depending on Info is affected by a change of Info.Name, and a field depending on Info.Name
+// is affected by a change of Info. A recomputed derived field is also a change.
+type DependencyGraph[F Field, T MapType[F]] struct {
+	parentOf func(field F) F
+
+	// rules in topological order
+	rules []DependencyData[F]
+}
+
+// NewDependencyGraph creates a DependencyGraph, panics if a field has several rules
+// or the rules form a cycle, including a field depending on itself, its ancestors or its descendants
+func NewDependencyGraph[F Field, T MapType[F]](
+	fieldMap *FieldMap[F, T], rules ...DependencyData[F],
+) *DependencyGraph[F, T] {
+	g := &DependencyGraph[F, T]{
+		parentOf: fieldMap.mappingParentOf,
+	}
+
+	ruleOf := map[F]emptyStruct{}
+	for _, rule := range rules {
+		if _, existed := ruleOf[rule.field]; existed {
+			panic(fmt.Sprintf("duplicated dependency rule for field %q", fieldMap.GetFullFieldName(rule.field)))
+		}
+		ruleOf[rule.field] = emptyStruct{}
+	}
+
+	// next[i] contains the rules affected by recomputing the field of rules[i]
+	next := make([][]int, len(rules))
+	inDegree := make([]int, len(rules))
+	for i, rule := range rules {
+		for j, other := range rules {
+			if g.affects(rule.field, other.dependsOn) {
+				next[i] = append(next[i], j)
+				inDegree[j]++
+			}
+		}
+	}
+
+	done := make([]bool, len(rules))
+	for len(g.rules) < len(rules) {
+		index := -1
+		for i := range rules {
+			if !done[i] && inDegree[i] == 0 {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			cycle := findDependencyCycle(next, done)
+			names := make([]string, 0, len(cycle))
+			for _, i := range cycle {
+				names = append(names, fieldMap.GetFullFieldName(rules[i].field))
+			}
+			panic(fmt.Sprintf("cycle in dependencies: %s", strings.Join(names, " -> ")))
+		}
+
+		done[index] = true
+		g.rules = append(g.rules, rules[index])
+		for _, j := range next[index] {
+			inDegree[j]--
+		}
+	}
+
+	return g
+}
+
+// findDependencyCycle returns the indices of rules in a cycle among rules that are not done,
+// the first one is repeated at the end
+func findDependencyCycle(next [][]int, done []bool) []int {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make([]int, len(next))
+	var path []int
+
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		state[i] = visiting
+		path = append(path, i)
+
+		for _, j := range next[i] {
+			if done[j] {
+				continue
+			}
+			switch state[j] {
+			case visiting:
+				for k, n := range path {
+					if n == j {
+						return append(append([]int(nil), path[k:]...), j)
+					}
+				}
+			case 0:
+				if cycle := visit(j); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range next {
+		if done[i] || state[i] != 0 {
+			continue
+		}
+		if cycle := visit(i); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+func (g *DependencyGraph[F, T]) isAncestorOrSelf(ancestor F, field F) bool {
+	var empty F
+	for field != empty {
+		if field == ancestor {
+			return true
+		}
+		field = g.parentOf(field)
+	}
+	return false
+}
+
+// affects checks whether a change of the field affects a rule depending on the fields dependsOn
+func (g *DependencyGraph[F, T]) affects(field F, dependsOn []F) bool {
+	for _, d := range dependsOn {
+		if g.isAncestorOrSelf(d, field) || g.isAncestorOrSelf(field, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Order returns the derived fields in topological order, a field is after all derived fields it depends on.
+// Ties are broken by the order of rules.
+func (g *DependencyGraph[F, T]) Order() []F {
+	result := make([]F, 0, len(g.rules))
+	for _, rule := range g.rules {
+		result = append(result, rule.field)
+	}
+	return result
+}
+
+// Closure returns the changed fields followed by the derived fields affected by them directly or transitively,
+// in the recomputation order, see Order
+func (g *DependencyGraph[F, T]) Closure(changed []F) []F {
+	var result []F
+	resultSet := map[F]emptyStruct{}
+
+	for _, field := range changed {
+		if _, existed := resultSet[field]; existed {
+			continue
+		}
+		resultSet[field] = emptyStruct{}
+		result = append(result, field)
+	}
+
+	for _, rule := range g.rules {
+		if _, existed := resultSet[rule.field]; existed {
+			continue
+		}
+		if !g.affectedBy(rule, result) {
+			continue
+		}
+		resultSet[rule.field] = emptyStruct{}
+		result = append(result, rule.field)
+	}
+
+	return result
+}
+
+func (g *DependencyGraph[F, T]) affectedBy(rule DependencyData[F], changed []F) bool {
+	for _, field := range changed {
+		if g.affects(field, rule.dependsOn) {
+			return true
+		}
+	}
+	return false
+}
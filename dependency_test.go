@@ -0,0 +1,95 @@
+package fieldmap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDependencyGraph(t *testing.T) {
+	fm := New[destField, destDataComplex]()
+	dest := fm.GetMapping()
+
+	t.Run("transitive closure", func(t *testing.T) {
+		g := NewDependencyGraph(fm,
+			NewDependency(dest.Detail.Body, dest.SearchText),
+			NewDependency(dest.SearchText, dest.Info.Name, dest.Info.Sku),
+		)
+
+		assert.Equal(t, []destField{dest.SearchText, dest.Detail.Body}, g.Order())
+
+		assert.Equal(t, []destField{dest.Info.Name, dest.SearchText, dest.Detail.Body},
+			g.Closure([]destField{dest.Info.Name}))
+		assert.Equal(t, []destField{dest.Info.Sku, dest.Info.Name, dest.SearchText, dest.Detail.Body},
+			g.Closure([]destField{dest.Info.Sku, dest.Info.Name, dest.Info.Sku}))
+		assert.Equal(t, []destField{dest.SearchText, dest.Detail.Body},
+			g.Closure([]destField{dest.SearchText}))
+		assert.Equal(t, []destField{dest.Detail.Body}, g.Closure([]destField{dest.Detail.Body}))
+		assert.Equal(t, 0, len(g.Closure(nil)))
+	})
+
+	t.Run("ancestors and descendants", func(t *testing.T) {
+		g := NewDependencyGraph(fm,
+			NewDependency(dest.SearchText, dest.Info.Root),
+			NewDependency(dest.Detail.Body, dest.Info.Sku),
+		)
+
+		assert.Equal(t, []destField{dest.Info.Name, dest.SearchText},
+			g.Closure([]destField{dest.Info.Name}))
+		assert.Equal(t, []destField{dest.Info.Root, dest.SearchText, dest.Detail.Body},
+			g.Closure([]destField{dest.Info.Root}))
+		assert.Equal(t, []destField{dest.Root, dest.SearchText, dest.Detail.Body},
+			g.Closure([]destField{dest.Root}))
+	})
+
+	t.Run("recomputed struct field", func(t *testing.T) {
+		g := NewDependencyGraph(fm,
+			NewDependency(dest.Info.Root, dest.Detail.Body),
+			NewDependency(dest.SearchText, dest.Info.Name),
+		)
+		assert.Equal(t, []destField{dest.Detail.Body, dest.Info.Root, dest.SearchText},
+			g.Closure([]destField{dest.Detail.Body}))
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		assert.PanicsWithValue(t, "cycle in dependencies: SearchText -> Detail.Body -> SearchText", func() {
+			NewDependencyGraph(fm,
+				NewDependency(dest.Info.Name, dest.Info.Sku),
+				NewDependency(dest.SearchText, dest.Detail.Body),
+				NewDependency(dest.Detail.Body, dest.SearchText, dest.Info.Name),
+			)
+		})
+		assert.PanicsWithValue(t, "cycle in dependencies: Info.Name -> Info.Name", func() {
+			NewDependencyGraph(fm,
+				NewDependency(dest.Info.Name, dest.Info.Root),
+			)
+		})
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.PanicsWithValue(t, "missing dependencies", func() {
+			NewDependency(dest.SearchText)
+		})
+		assert.PanicsWithValue(t, `duplicated dependency rule for field "SearchText"`, func() {
+			NewDependencyGraph(fm,
+				NewDependency(dest.SearchText, dest.Info.Name),
+				NewDependency(dest.SearchText, dest.Info.Sku),
+			)
+		})
+	})
+}
+
+func TestDependencyGraph_MapNode(t *testing.T) {
+	fm := New[sourceField, sourceCatalog]()
+	source := fm.GetMapping()
+
+	color := fm.RegisterKey(source.Attributes, "color")
+
+	g := NewDependencyGraph(fm,
+		NewDependency(source.Sku, source.Attributes.Any),
+	)
+	assert.Equal(t, []sourceField{color, source.Sku}, g.Closure([]sourceField{color}))
+
+	size := fm.RegisterKey(source.Attributes, "size")
+	assert.Equal(t, []sourceField{size, source.Sku}, g.Closure([]sourceField{size}))
+	assert.Equal(t, []sourceField{source.Attributes.Root, source.Sku}, g.Closure([]sourceField{source.Attributes.Root}))
+}
@@ -0,0 +1,124 @@
+package fieldmap
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DumpOptions ...
+type DumpOptions struct {
+	// Indent for each level of the tree, default is two spaces
+	Indent string
+
+	// Tags to show, default is all configured struct tags
+	Tags []string
+}
+
+// errWriter keeps the first error of writes, for writing many lines without checking errors each time
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errWriter) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.w, format, args...)
+}
+
+const rootDisplayName = "<root>"
+
+func (f *FieldMap[F, T]) displayName(field F) string {
+	if field == f.structRoot {
+		return rootDisplayName
+	}
+	return f.GetFieldName(field)
+}
+
+func (f *FieldMap[F, T]) displayFullName(field F) string {
+	if field == f.structRoot {
+		return rootDisplayName
+	}
+	return f.GetFullFieldName(field)
+}
+
+// Dump writes the field tree, one field per line with ordinal, field name, full field name,
+// struct tags and attributes. The output is deterministic.
+func (f *FieldMap[F, T]) Dump(w io.Writer, opts DumpOptions) error {
+	if len(opts.Indent) == 0 {
+		opts.Indent = "  "
+	}
+	if opts.Tags == nil {
+		opts.Tags = f.options.structTags
+	}
+
+	ew := &errWriter{w: w}
+	f.dumpField(ew, f.structRoot, 0, opts)
+	return ew.err
+}
+
+func (f *FieldMap[F, T]) dumpField(w *errWriter, field F, level int, opts DumpOptions) {
+	w.printf("%s%d %s", strings.Repeat(opts.Indent, level), f.indexOf(field)+1, f.displayName(field))
+
+	if field != f.structRoot {
+		w.printf(" (%s)", f.GetFullFieldName(field))
+		for _, tag := range opts.Tags {
+			w.printf(" %s:%q", tag, f.GetStructTag(tag, field))
+		}
+	}
+	if attrs := f.GetAttributes(field); attrs != 0 {
+		w.printf(" [%s]", attrs)
+	}
+	w.printf("\n")
+
+	for _, child := range f.ChildrenOf(field) {
+		f.dumpField(w, child, level+1, opts)
+	}
+}
+
+func (f *FieldMap[F, T]) graphLabel(field F) string {
+	return fmt.Sprintf("%d: %s", f.indexOf(field)+1, f.displayName(field))
+}
+
+func escapeGraphLabel(label string) string {
+	return strings.ReplaceAll(label, `"`, `\"`)
+}
+
+// WriteDOT writes the field tree in the Graphviz DOT language
+func (f *FieldMap[F, T]) WriteDOT(w io.Writer) error {
+	ew := &errWriter{w: w}
+
+	ew.printf("digraph fieldmap {\n")
+	ew.printf("  node [shape=box];\n")
+	for _, field := range f.fields {
+		ew.printf("  f%d [label=\"%s\"];\n", f.indexOf(field)+1, escapeGraphLabel(f.graphLabel(field)))
+	}
+	for _, field := range f.fields {
+		for _, child := range f.ChildrenOf(field) {
+			ew.printf("  f%d -> f%d;\n", f.indexOf(field)+1, f.indexOf(child)+1)
+		}
+	}
+	ew.printf("}\n")
+
+	return ew.err
+}
+
+// WriteMermaid writes the field tree as a Mermaid flowchart
+func (f *FieldMap[F, T]) WriteMermaid(w io.Writer) error {
+	ew := &errWriter{w: w}
+
+	ew.printf("graph TD\n")
+	for _, field := range f.fields {
+		label := escapeMermaidLabel(f.graphLabel(field))
+		ew.printf("  f%d[\"%s\"]\n", f.indexOf(field)+1, label)
+	}
+	for _, field := range f.fields {
+		for _, child := range f.ChildrenOf(field) {
+			ew.printf("  f%d --> f%d\n", f.indexOf(field)+1, f.indexOf(child)+1)
+		}
+	}
+
+	return ew.err
+}
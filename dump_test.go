@@ -0,0 +1,107 @@
+package fieldmap
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type errorWriter struct {
+}
+
+func (errorWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write error")
+}
+
+func TestFieldMap_Dump(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		fm := New[field, productData](WithStructTags("json"))
+
+		var buf strings.Builder
+		err := fm.Dump(&buf, DumpOptions{})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `1 <root>
+  2 Sku (Sku) json:"sku"
+  3 Name (Name) json:"name"
+  4 Seller (Seller) json:"seller"
+    5 ID (Seller.ID) json:"id"
+    6 Name (Seller.Name) json:"name"
+    7 Logo (Seller.Logo) json:"logo"
+    8 Attr (Seller.Attr) json:"attr"
+      9 Code (Seller.Attr.Code) json:"code"
+      10 Name (Seller.Attr.Name) json:"name"
+  11 ImageURL (ImageURL) json:"imageUrl"
+`, buf.String())
+	})
+
+	t.Run("with attributes and map keys", func(t *testing.T) {
+		fm := New[field, catalogData](WithStructTags("json"))
+		fm.RegisterKey(fm.GetMapping().Attributes, "color")
+
+		var buf strings.Builder
+		err := fm.Dump(&buf, DumpOptions{Indent: "\t", Tags: []string{}})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "1 <root>\n"+
+			"\t2 Sku (Sku)\n"+
+			"\t3 Attributes (Attributes)\n"+
+			"\t\t4 * (Attributes.*)\n"+
+			"\t\t6 color (Attributes.color)\n"+
+			"\t5 Name (Name)\n", buf.String())
+
+		buf.Reset()
+		err = New[field, sellerWithAttrsData]().Dump(&buf, DumpOptions{})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `1 <root>
+  2 Seller (Seller) [deprecated]
+    3 ID (Seller.ID) [immutable,deprecated]
+    4 Name (Seller.Name) [required,deprecated]
+`, buf.String())
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		fm := New[field, productData]()
+		assert.Equal(t, errors.New("write error"), fm.Dump(errorWriter{}, DumpOptions{}))
+		assert.Equal(t, errors.New("write error"), fm.WriteDOT(errorWriter{}))
+		assert.Equal(t, errors.New("write error"), fm.WriteMermaid(errorWriter{}))
+	})
+}
+
+type sellerWithAttrsData struct {
+	Root   field
+	Seller sellerWithAttrs `fieldmap:"deprecated"`
+}
+
+func (d sellerWithAttrsData) GetRoot() field { return d.Root }
+
+func TestFieldMap_WriteGraph(t *testing.T) {
+	fm := New[field, sellerWithAttrsData]()
+
+	var buf strings.Builder
+	err := fm.WriteDOT(&buf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `digraph fieldmap {
+  node [shape=box];
+  f1 [label="1: <root>"];
+  f2 [label="2: Seller"];
+  f3 [label="3: ID"];
+  f4 [label="4: Name"];
+  f1 -> f2;
+  f2 -> f3;
+  f2 -> f4;
+}
+`, buf.String())
+
+	buf.Reset()
+	err = fm.WriteMermaid(&buf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `graph TD
+  f1["1: <root>"]
+  f2["2: Seller"]
+  f3["3: ID"]
+  f4["4: Name"]
+  f1 --> f2
+  f2 --> f3
+  f2 --> f4
+`, buf.String())
+}
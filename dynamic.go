@@ -0,0 +1,161 @@
+package fieldmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SchemaNode describes a field of a schema defined at runtime, such as a form builder configuration.
+// The top-level node is the root of the schema, its name and tags are ignored.
+// A node with children is a struct, a node with Map = true is similar to MapNode.
+type SchemaNode struct {
+	Name       string            `json:"name,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Attributes []string          `json:"attributes,omitempty"`
+	Map        bool              `json:"map,omitempty"`
+	ID         uint32            `json:"id,omitempty"`
+	Children   []SchemaNode      `json:"children,omitempty"`
+}
+
+// LoadSchema reads a SchemaNode in JSON format
+func LoadSchema(r io.Reader) (SchemaNode, error) {
+	var schema SchemaNode
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&schema); err != nil {
+		return SchemaNode{}, fmt.Errorf("decode schema: %w", err)
+	}
+	return schema, nil
+}
+
+// DynamicMapping is the mapping type of a DynamicFieldMap.
+// Fields are looked up by full field names instead of struct members.
+type DynamicMapping[F Field] struct {
+	root F
+}
+
+// GetRoot ...
+func (m DynamicMapping[F]) GetRoot() F {
+	return m.root
+}
+
+// DynamicFieldMap is a FieldMap built from a SchemaNode instead of a mapping struct.
+// The embedded FieldMap can be used as source or destination of a Mapper.
+type DynamicFieldMap[F Field] struct {
+	*FieldMap[F, DynamicMapping[F]]
+}
+
+// NewDynamic creates a DynamicFieldMap, ordinals are assigned in the same way as New
+func NewDynamic[F Field](schema SchemaNode, options ...Option) (*DynamicFieldMap[F], error) {
+	f := newFieldMap[F, DynamicMapping[F]](options)
+
+	var empty F
+	if err := f.buildFromSchema(schema, empty, "", 0); err != nil {
+		return nil, err
+	}
+
+	f.mapping = DynamicMapping[F]{root: f.structRoot}
+
+	return &DynamicFieldMap[F]{FieldMap: f}, nil
+}
+
+// Field returns the field with the full field name, panics if not found
+func (d *DynamicFieldMap[F]) Field(fullName string) F {
+	field, ok := d.FindByFullFieldName(fullName)
+	if !ok {
+		panic(fmt.Sprintf("not found field %q", fullName))
+	}
+	return field
+}
+
+// MapNode returns the map node with the full field name, panics if not found
+func (d *DynamicFieldMap[F]) MapNode(fullName string) MapNode[F] {
+	root := d.Field(fullName)
+	data, ok := d.mapNodes[root]
+	if !ok {
+		panic(fmt.Sprintf("field %q is not a map node", fullName))
+	}
+	return MapNode[F]{Root: root, Any: data.anyField}
+}
+
+func (f *FieldMap[F, T]) buildFromSchema(
+	node SchemaNode, parent F, fullFieldName string, parentAttrs Attribute,
+) error {
+	var empty F
+	isRoot := parent == empty
+
+	attrs, err := parseAttributeNames(node.Attributes)
+	if err != nil {
+		return fmt.Errorf("invalid attributes for field %q: %w", fullFieldName, err)
+	}
+	attrs |= parentAttrs
+
+	structTags := map[string]string{}
+	if !isRoot {
+		for _, tag := range f.options.structTags {
+			tagVal := node.Tags[tag]
+			if len(tagVal) == 0 {
+				return fmt.Errorf("missing struct tag %q for field %q", tag, fullFieldName)
+			}
+			structTags[tag] = tagVal
+		}
+	}
+
+	if node.Map {
+		if isRoot || len(node.Children) > 0 {
+			return fmt.Errorf("invalid map node %q", fullFieldName)
+		}
+		mapNode := f.appendMapNode(parent, node.Name, structTags, attrs)
+		return f.setStableID(mapNode.Root, node.ID)
+	}
+
+	fieldName := node.Name
+	if isRoot {
+		fieldName = ""
+	}
+
+	field := f.appendField(parent, fieldName, structTags, attrs)
+	if isRoot {
+		f.structRoot = field
+	} else if err := f.setStableID(field, node.ID); err != nil {
+		return err
+	}
+	return f.buildChildrenFromSchema(node, field, fullFieldName, attrs)
+}
+
+func (f *FieldMap[F, T]) buildChildrenFromSchema(
+	node SchemaNode, field F, fullFieldName string, attrs Attribute,
+) error {
+	names := map[string]emptyStruct{}
+	for _, child := range node.Children {
+		childFullName := joinFieldName(fullFieldName, child.Name)
+
+		if len(child.Name) == 0 || strings.Contains(child.Name, ".") || child.Name == AnyKeyName {
+			return fmt.Errorf("invalid field name %q", childFullName)
+		}
+		if _, existed := names[child.Name]; existed {
+			return fmt.Errorf("duplicated field %q", childFullName)
+		}
+		names[child.Name] = emptyStruct{}
+
+		if err := f.buildFromSchema(child, field, childFullName, attrs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseAttributeNames(names []string) (Attribute, error) {
+	var attrs Attribute
+	for _, name := range names {
+		attr, ok := parseAttribute(name)
+		if !ok {
+			return 0, fmt.Errorf("unknown attribute %q", name)
+		}
+		attrs |= attr
+	}
+	return attrs, nil
+}
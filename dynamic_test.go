@@ -0,0 +1,131 @@
+package fieldmap
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const productSchemaJSON = `{
+  "children": [
+    {"name": "Sku", "tags": {"json": "sku"}, "attributes": ["immutable"]},
+    {"name": "Name", "tags": {"json": "name"}},
+    {"name": "Seller", "tags": {"json": "seller"}, "attributes": ["readonly"], "children": [
+      {"name": "ID", "tags": {"json": "id"}},
+      {"name": "Name", "tags": {"json": "name"}}
+    ]},
+    {"name": "Attributes", "tags": {"json": "attributes"}, "map": true},
+    {"name": "ImageURL", "tags": {"json": "imageUrl"}}
+  ]
+}`
+
+func newProductDynamic(t *testing.T) *DynamicFieldMap[sourceField] {
+	schema, err := LoadSchema(strings.NewReader(productSchemaJSON))
+	assert.Equal(t, nil, err)
+
+	fm, err := NewDynamic[sourceField](schema, WithStructTags("json"))
+	assert.Equal(t, nil, err)
+	return fm
+}
+
+func TestDynamicFieldMap(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		fm := newProductDynamic(t)
+
+		root := fm.GetMapping().GetRoot()
+		sku := fm.Field("Sku")
+		sellerRoot := fm.Field("Seller")
+		sellerName := fm.Field("Seller.Name")
+		attrs := fm.MapNode("Attributes")
+		imageURL := fm.Field("ImageURL")
+
+		assert.Equal(t, sourceField(1), root)
+		assert.Equal(t, sourceField(2), sku)
+		assert.Equal(t, sourceField(4), sellerRoot)
+		assert.Equal(t, sourceField(6), sellerName)
+		assert.Equal(t, MapNode[sourceField]{Root: 7, Any: 8}, attrs)
+		assert.Equal(t, sourceField(9), imageURL)
+
+		assert.Equal(t, []sourceField{2, 3, 4, 7, 9}, fm.ChildrenOf(root))
+		assert.Equal(t, []sourceField{5, 6}, fm.ChildrenOf(sellerRoot))
+		assert.Equal(t, sellerRoot, fm.ParentOf(sellerName))
+		assert.Equal(t, true, fm.IsStruct(sellerRoot))
+
+		assert.Equal(t, "Seller.Name", fm.GetFullFieldName(sellerName))
+		assert.Equal(t, "seller.name", fm.GetFullStructTag("json", sellerName))
+
+		assert.Equal(t, true, fm.HasAttribute(sku, AttributeImmutable))
+		assert.Equal(t, true, fm.HasAttribute(sellerName, AttributeReadonly))
+
+		color := fm.RegisterKey(attrs, "color")
+		assert.Equal(t, "attributes.color", fm.GetFullStructTag("json", color))
+	})
+
+	t.Run("missing tag", func(t *testing.T) {
+		schema := SchemaNode{
+			Children: []SchemaNode{
+				{Name: "Seller", Tags: map[string]string{"json": "seller"}, Children: []SchemaNode{
+					{Name: "ID"},
+				}},
+			},
+		}
+		_, err := NewDynamic[sourceField](schema, WithStructTags("json"))
+		assert.Equal(t, errors.New(`missing struct tag "json" for field "Seller.ID"`), err)
+	})
+
+	t.Run("duplicated field", func(t *testing.T) {
+		schema := SchemaNode{
+			Children: []SchemaNode{{Name: "Sku"}, {Name: "Sku"}},
+		}
+		_, err := NewDynamic[sourceField](schema)
+		assert.Equal(t, errors.New(`duplicated field "Sku"`), err)
+	})
+
+	t.Run("invalid field name", func(t *testing.T) {
+		schema := SchemaNode{
+			Children: []SchemaNode{{Name: "Seller.ID"}},
+		}
+		_, err := NewDynamic[sourceField](schema)
+		assert.Equal(t, errors.New(`invalid field name "Seller.ID"`), err)
+	})
+
+	t.Run("unknown attribute", func(t *testing.T) {
+		schema := SchemaNode{
+			Children: []SchemaNode{{Name: "Sku", Attributes: []string{"hidden"}}},
+		}
+		_, err := NewDynamic[sourceField](schema)
+		assert.Equal(t, `invalid attributes for field "Sku": unknown attribute "hidden"`, err.Error())
+	})
+
+	t.Run("load unknown json field", func(t *testing.T) {
+		_, err := LoadSchema(strings.NewReader(`{"fields": []}`))
+		assert.Equal(t, `decode schema: json: unknown field "fields"`, err.Error())
+	})
+}
+
+func TestDynamicFieldMap_Mapping(t *testing.T) {
+	sourceFm := newProductDynamic(t)
+	destFm := New[destField, destDataComplex]()
+
+	dest := destFm.GetMapping()
+
+	m := NewMapper(
+		sourceFm.FieldMap, destFm,
+		WithSimpleMapping(sourceFm.FieldMap, destFm,
+			NewMapping(sourceFm.Field("Sku"), dest.Info.Sku),
+			NewMapping(sourceFm.Field("Seller"), dest.Detail.Root),
+		),
+	)
+
+	assert.Equal(t, []destField{dest.Info.Sku}, m.FindMappedFields([]sourceField{sourceFm.Field("Sku")}))
+	assert.Equal(t, []destField{dest.Detail.Root}, m.FindMappedFields([]sourceField{sourceFm.Field("Seller.ID")}))
+
+	reverse := NewMapper(
+		destFm, sourceFm.FieldMap,
+		WithSimpleMapping(destFm, sourceFm.FieldMap,
+			NewMapping(dest.Info.Root, sourceFm.Field("Name")),
+		),
+	)
+	assert.Equal(t, []sourceField{sourceFm.Field("Name")}, reverse.FindMappedFields([]destField{dest.Info.Sku}))
+}
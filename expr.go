@@ -0,0 +1,241 @@
+package fieldmap
+
+import (
+	"context"
+	"strings"
+)
+
+type exprKind int
+
+const (
+	exprDest exprKind = iota + 1
+	exprAllOf
+	exprAnyOf
+)
+
+// Expr is a combination of destination fields of a mapping rule, created by Dest, AllOf and AnyOf.
+// An expression is matched if it has no condition or its condition set by When is true, and:
+//
+//   - Dest is always matched, the result is its field
+//   - AllOf is matched if all of its sub expressions are matched, the result is the union of their results
+//   - AnyOf is matched if one of its sub expressions is matched, the result is the result of the first one
+//
+// A rule whose expression is not matched is skipped, the same as a rule with a false condition.
+type Expr[F Field] struct {
+	kind     exprKind
+	field    F
+	children []Expr[F]
+
+	when func(ctx context.Context) bool
+}
+
+// Dest is an expression of a single destination field
+func Dest[F Field](field F) Expr[F] {
+	return Expr[F]{kind: exprDest, field: field}
+}
+
+// AllOf is an expression matched if all sub expressions are matched
+func AllOf[F Field](exprs ...Expr[F]) Expr[F] {
+	return newGroupExpr(exprAllOf, exprs)
+}
+
+// AnyOf is an expression matched by its first matched sub expression
+func AnyOf[F Field](exprs ...Expr[F]) Expr[F] {
+	return newGroupExpr(exprAnyOf, exprs)
+}
+
+func newGroupExpr[F Field](kind exprKind, exprs []Expr[F]) Expr[F] {
+	if len(exprs) == 0 {
+		panic("missing sub expressions")
+	}
+	for _, e := range exprs {
+		e.mustValid()
+	}
+	return Expr[F]{kind: kind, children: exprs}
+}
+
+func (e Expr[F]) mustValid() {
+	if e.kind == 0 {
+		panic("invalid expression")
+	}
+}
+
+// When returns a copy of the expression that is only matched when the condition is true
+// for the context passed to FindMappedFieldsCtx
+func (e Expr[F]) When(cond func(ctx context.Context) bool) Expr[F] {
+	if cond == nil {
+		panic("nil condition of expression")
+	}
+	e.mustValid()
+	e.when = cond
+	return e
+}
+
+// conditional returns true if the expression or one of its sub expressions has a condition
+func (e Expr[F]) conditional() bool {
+	if e.when != nil {
+		return true
+	}
+	for _, child := range e.children {
+		if child.conditional() {
+			return true
+		}
+	}
+	return false
+}
+
+// fields returns distinct destination fields of the expression
+func (e Expr[F]) fields() []F {
+	var result []F
+	seen := map[F]emptyStruct{}
+
+	var visit func(e Expr[F])
+	visit = func(e Expr[F]) {
+		if e.kind == exprDest {
+			if _, ok := seen[e.field]; !ok {
+				seen[e.field] = emptyStruct{}
+				result = append(result, e.field)
+			}
+			return
+		}
+		for _, child := range e.children {
+			visit(child)
+		}
+	}
+	visit(e)
+
+	return result
+}
+
+func (e Expr[F]) mapFields(fn func(field F) F) Expr[F] {
+	if e.kind == exprDest {
+		e.field = fn(e.field)
+		return e
+	}
+
+	children := make([]Expr[F], 0, len(e.children))
+	for _, child := range e.children {
+		children = append(children, child.mapFields(fn))
+	}
+	e.children = children
+	return e
+}
+
+// findDuplicate returns a destination field that is duplicated in an AllOf group, including nested AllOf groups,
+// or a single field duplicated between alternatives without conditions of an AnyOf group
+func (e Expr[F]) findDuplicate() (F, bool) {
+	var empty F
+
+	switch e.kind {
+	case exprAllOf:
+		return e.findDuplicateInAllOf(map[F]emptyStruct{})
+
+	case exprAnyOf:
+		alternatives := map[F]emptyStruct{}
+		for _, child := range e.children {
+			if dup, ok := child.findDuplicate(); ok {
+				return dup, true
+			}
+			if child.conditional() {
+				continue
+			}
+			if fields := child.fields(); len(fields) == 1 {
+				if _, existed := alternatives[fields[0]]; existed {
+					return fields[0], true
+				}
+				alternatives[fields[0]] = emptyStruct{}
+			}
+		}
+	}
+	return empty, false
+}
+
+func (e Expr[F]) findDuplicateInAllOf(seen map[F]emptyStruct) (F, bool) {
+	for _, child := range e.children {
+		switch child.kind {
+		case exprDest:
+			if _, existed := seen[child.field]; existed {
+				return child.field, true
+			}
+			seen[child.field] = emptyStruct{}
+
+		case exprAllOf:
+			if dup, ok := child.findDuplicateInAllOf(seen); ok {
+				return dup, true
+			}
+
+		default:
+			if dup, ok := child.findDuplicate(); ok {
+				return dup, true
+			}
+		}
+	}
+	var empty F
+	return empty, false
+}
+
+// alternatives returns the results of all the ways the expression can be matched, in order of preference:
+// each branch of AnyOf is an alternative, AllOf combines the alternatives of its sub expressions.
+// The first alternative is the result of the expression as described in Expr.
+func (e Expr[F]) alternatives(ctx context.Context) [][]F {
+	if e.when != nil && !e.when(ctx) {
+		return nil
+	}
+
+	switch e.kind {
+	case exprDest:
+		return [][]F{{e.field}}
+
+	case exprAllOf:
+		result := [][]F{nil}
+		for _, child := range e.children {
+			childAlternatives := child.alternatives(ctx)
+			next := make([][]F, 0, len(result)*len(childAlternatives))
+			for _, prefix := range result {
+				for _, alternative := range childAlternatives {
+					combined := append(append([]F(nil), prefix...), alternative...)
+					next = append(next, combined)
+				}
+			}
+			result = next
+		}
+		return result
+
+	default:
+		var result [][]F
+		for _, child := range e.children {
+			result = append(result, child.alternatives(ctx)...)
+		}
+		return result
+	}
+}
+
+// format writes the expression with AND / OR operators, nested groups are parenthesized
+func (e Expr[F]) format(buf *strings.Builder, name func(field F) string, nested bool) {
+	if e.kind == exprDest {
+		buf.WriteString(name(e.field))
+	} else {
+		op := " AND "
+		if e.kind == exprAnyOf {
+			op = " OR "
+		}
+
+		parenthesized := len(e.children) > 1 && (nested || e.when != nil)
+		if parenthesized {
+			buf.WriteString("(")
+		}
+		for i, child := range e.children {
+			if i > 0 {
+				buf.WriteString(op)
+			}
+			child.format(buf, name, true)
+		}
+		if parenthesized {
+			buf.WriteString(")")
+		}
+	}
+
+	if e.when != nil {
+		buf.WriteString(" [when]")
+	}
+}
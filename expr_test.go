@@ -0,0 +1,249 @@
+package fieldmap
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestExpr(t *testing.T) {
+	t.Run("fields", func(t *testing.T) {
+		e := AllOf(Dest[destField](3), AnyOf(Dest[destField](4), AllOf(Dest[destField](3), Dest[destField](5))))
+		assert.Equal(t, []destField{3, 4, 5}, e.fields())
+		assert.Equal(t, false, e.conditional())
+	})
+
+	t.Run("alternatives", func(t *testing.T) {
+		never := func(ctx context.Context) bool { return false }
+
+		e := AllOf(Dest[destField](3), AnyOf(Dest[destField](4).When(never), Dest[destField](5)))
+		assert.Equal(t, [][]destField{{3, 5}}, e.alternatives(context.Background()))
+		assert.Equal(t, true, e.conditional())
+
+		e = AnyOf(AllOf(Dest[destField](3), Dest[destField](4).When(never)), Dest[destField](6))
+		assert.Equal(t, [][]destField{{6}}, e.alternatives(context.Background()))
+
+		e = AnyOf(Dest[destField](3).When(never))
+		assert.Equal(t, 0, len(e.alternatives(context.Background())))
+
+		e = AllOf(
+			AnyOf(Dest[destField](2), Dest[destField](3)),
+			AnyOf(Dest[destField](4), AllOf(Dest[destField](5), Dest[destField](6))),
+		)
+		assert.Equal(t, [][]destField{{2, 4}, {2, 5, 6}, {3, 4}, {3, 5, 6}}, e.alternatives(context.Background()))
+	})
+
+	t.Run("find duplicate", func(t *testing.T) {
+		always := func(ctx context.Context) bool { return true }
+
+		_, ok := AllOf(Dest[destField](3), AnyOf(Dest[destField](3), Dest[destField](4))).findDuplicate()
+		assert.Equal(t, false, ok)
+
+		dup, ok := AllOf(Dest[destField](3), AllOf(Dest[destField](4), Dest[destField](3))).findDuplicate()
+		assert.Equal(t, true, ok)
+		assert.Equal(t, destField(3), dup)
+
+		dup, ok = AnyOf(AllOf(Dest[destField](4)), Dest[destField](5), Dest[destField](4)).findDuplicate()
+		assert.Equal(t, true, ok)
+		assert.Equal(t, destField(4), dup)
+
+		_, ok = AnyOf(Dest[destField](4).When(always), Dest[destField](4)).findDuplicate()
+		assert.Equal(t, false, ok)
+
+		dup, ok = AnyOf(Dest[destField](2), AllOf(Dest[destField](5), Dest[destField](5))).findDuplicate()
+		assert.Equal(t, true, ok)
+		assert.Equal(t, destField(5), dup)
+	})
+
+	t.Run("format", func(t *testing.T) {
+		always := func(ctx context.Context) bool { return true }
+		name := func(field destField) string {
+			return string(rune('a' + field))
+		}
+
+		format := func(e Expr[destField]) string {
+			var buf strings.Builder
+			e.format(&buf, name, false)
+			return buf.String()
+		}
+
+		assert.Equal(t, "b", format(Dest[destField](1)))
+		assert.Equal(t, "b AND (c OR (d AND e)) AND f", format(AllOf(
+			Dest[destField](1),
+			AnyOf(Dest[destField](2), AllOf(Dest[destField](3), Dest[destField](4))),
+			AllOf(Dest[destField](5)),
+		)))
+		assert.Equal(t, "(b OR c [when]) [when]", format(AnyOf(
+			Dest[destField](1), Dest[destField](2).When(always),
+		).When(always)))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.PanicsWithValue(t, "missing sub expressions", func() {
+			AllOf[destField]()
+		})
+		assert.PanicsWithValue(t, "invalid expression", func() {
+			AnyOf(Dest[destField](1), Expr[destField]{})
+		})
+		assert.PanicsWithValue(t, "invalid expression", func() {
+			NewMappingExpr(sourceField(1), Expr[destField]{})
+		})
+		assert.PanicsWithValue(t, "nil condition of expression", func() {
+			Dest[destField](1).When(nil)
+		})
+	})
+}
+
+func TestMapping_Expr(t *testing.T) {
+	sourceFm := New[sourceField, sourceDataComplex]()
+	destFm := New[destField, destDataComplex]()
+
+	source := sourceFm.GetMapping()
+	dest := destFm.GetMapping()
+
+	t.Run("new mapping is sugar over expressions", func(t *testing.T) {
+		assert.Equal(t,
+			NewMappingExpr(source.Name, AllOf(Dest(dest.Info.Name), Dest(dest.SearchText))),
+			NewMapping(source.Name, dest.Info.Name, dest.SearchText),
+		)
+		assert.Equal(t,
+			NewMappingExpr(source.Name, Dest(dest.Info.Name)),
+			NewMapping(source.Name, dest.Info.Name),
+		)
+	})
+
+	t.Run("any of without conditions", func(t *testing.T) {
+		m := NewMapper(
+			sourceFm, destFm,
+			WithSimpleMapping(sourceFm, destFm,
+				NewMappingExpr(source.Sku, AnyOf(Dest(dest.Info.Sku), Dest(dest.SearchText))),
+				NewMappingExpr(source.Name, AllOf(
+					Dest(dest.Info.Name),
+					AnyOf(AllOf(Dest(dest.Detail.Body), Dest(dest.SearchText)), Dest(dest.Info.Sku)),
+				)),
+			),
+		)
+
+		assert.Equal(t, []destField{dest.Info.Sku}, m.FindMappedFields([]sourceField{source.Sku}))
+		assert.Equal(t, []destField{dest.Info.Name, dest.Detail.Body, dest.SearchText},
+			m.FindMappedFields([]sourceField{source.Name}))
+		assert.Equal(t, []destField{dest.Info.Sku, dest.SearchText}, m.Rules()[0].ToList())
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		m := NewMapper(
+			sourceFm, destFm,
+			WithSimpleMapping(sourceFm, destFm,
+				NewMappingExpr(source.Name, AllOf(
+					Dest(dest.SearchText),
+					AnyOf(
+						Dest(dest.Info.Name).When(isTenant("a")),
+						AllOf(Dest(dest.Detail.Body), Dest(dest.Info.Sku)),
+					),
+				)),
+				NewMappingExpr(source.Sku, AnyOf(
+					Dest(dest.Info.Sku).When(isTenant("a")),
+				)),
+			),
+		)
+
+		assert.Equal(t, []destField{dest.SearchText, dest.Info.Name},
+			m.FindMappedFieldsCtx(withTenant("a"), []sourceField{source.Name}))
+		assert.Equal(t, []destField{dest.SearchText, dest.Detail.Body, dest.Info.Sku},
+			m.FindMappedFields([]sourceField{source.Name}))
+
+		assert.Equal(t, []destField{dest.Info.Sku}, m.FindMappedFieldsCtx(withTenant("a"), []sourceField{source.Sku}))
+		assert.Equal(t, 0, len(m.FindMappedFields([]sourceField{source.Sku})))
+
+		rules := m.Rules()
+		assert.Equal(t, []destField{dest.SearchText, dest.Info.Name, dest.Detail.Body, dest.Info.Sku}, rules[0].ToList())
+		assert.Equal(t, true, rules[0].Conditional())
+
+		var buf strings.Builder
+		assert.Equal(t, nil, m.WriteMarkdownTable(&buf))
+		assert.Equal(t, `| # | Source | Destinations | Alternative | Inherited |
+|---|--------|--------------|-------------|-----------|
+| 1 | Name | SearchText AND (Info.Name [when] OR (Detail.Body AND Info.Sku)) | when |  |
+| 2 | Sku | Info.Sku [when] | when |  |
+`, buf.String())
+	})
+
+	t.Run("not matched falls back to parent", func(t *testing.T) {
+		m := NewMapper(
+			sourceFm, destFm,
+			WithSimpleMapping(sourceFm, destFm,
+				NewMapping(source.Seller.Root, dest.Detail.Root),
+				NewMappingExpr(source.Seller.ID, AllOf(
+					Dest(dest.Detail.Body),
+					Dest(dest.SearchText).When(isTenant("a")),
+				)),
+			),
+		)
+
+		assert.Equal(t, []destField{dest.Detail.Body, dest.SearchText},
+			m.FindMappedFieldsCtx(withTenant("a"), []sourceField{source.Seller.ID}))
+		assert.Equal(t, []destField{dest.Detail.Root},
+			m.FindMappedFieldsCtx(withTenant("b"), []sourceField{source.Seller.ID}))
+	})
+
+	t.Run("inherited", func(t *testing.T) {
+		subSourceFm := New[sourceField, sourceSeller]()
+		subDestFm := New[destField, destDetail]()
+
+		subMapper := NewMapper(
+			subSourceFm, subDestFm,
+			WithSimpleMapping(subSourceFm, subDestFm,
+				NewMappingExpr(subSourceFm.GetMapping().ID, AnyOf(
+					Dest(subDestFm.GetMapping().Body).When(isTenant("a")),
+					Dest(subDestFm.GetMapping().Root),
+				)),
+			),
+		)
+
+		m := NewMapper(
+			sourceFm, destFm,
+			WithInheritMapping(sourceFm, destFm, subMapper,
+				sourceDataComplex.GetSeller,
+				destDataComplex.GetDetail,
+			),
+		)
+
+		assert.Equal(t, []destField{dest.Detail.Body}, m.FindMappedFieldsCtx(withTenant("a"), []sourceField{source.Seller.ID}))
+		assert.Equal(t, []destField{dest.Detail.Root}, m.FindMappedFieldsCtx(withTenant("b"), []sourceField{source.Seller.ID}))
+		assert.Equal(t, []destField{dest.Detail.Body, dest.Detail.Root}, m.Rules()[0].ToList())
+	})
+
+	t.Run("duplicated in nested groups", func(t *testing.T) {
+		assert.PanicsWithValue(t, `duplicated destination field "Info.Name" for source field "Name"`, func() {
+			NewMapper(
+				sourceFm, destFm,
+				WithSimpleMapping(sourceFm, destFm,
+					NewMappingExpr(source.Name, AllOf(
+						Dest(dest.Info.Name),
+						AllOf(Dest(dest.SearchText), Dest(dest.Info.Name)),
+					)),
+				),
+			)
+		})
+
+		assert.PanicsWithValue(t, `duplicated destination field "SearchText" for source field "Sku"`, func() {
+			NewMapper(
+				sourceFm, destFm,
+				WithSimpleMapping(sourceFm, destFm,
+					NewMappingExpr(source.Sku, AnyOf(Dest(dest.SearchText), Dest(dest.SearchText))),
+				),
+			)
+		})
+
+		assert.PanicsWithValue(t, `duplicated destination field "SearchText" for source field "Sku"`, func() {
+			NewMapper(
+				sourceFm, destFm,
+				WithSimpleMapping(sourceFm, destFm,
+					NewMapping(source.Sku, dest.SearchText),
+					NewMappingExpr(source.Sku, AnyOf(Dest(dest.SearchText))),
+				),
+			)
+		})
+	})
+}
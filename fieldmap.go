@@ -3,6 +3,7 @@ package fieldmap
 import (
 	"fmt"
 	"reflect"
+	"sync"
 )
 
 // Field ...
@@ -15,7 +16,7 @@ type MapType[F Field] interface {
 	GetRoot() F
 }
 
-// RootField ...
+// RootField is the default name of the root field of mapping structs
 const RootField = "Root"
 
 // FieldMap ...
@@ -26,14 +27,33 @@ type FieldMap[F Field, T MapType[F]] struct {
 	fields     []F
 	structRoot F
 
-	children   []int64
+	children   [][]F
 	parentList []F
 	fieldNames []string
 	structTags map[string][]string
+	attributes []Attribute
+	dataTypes  []reflect.Type
+
+	fullNameIndex map[string]F
+	fullTagIndex  map[string]map[string]F
+
+	mapNodes map[F]*mapNodeData[F]
+	mapKeyOf map[F]F
+
+	stableIDs     []uint32
+	stableIDPaths map[F][]uint32
+	stableIDIndex map[string]F
+
+	costs map[F]int
+
+	fingerprintOnce sync.Once
+	fingerprint     string
 }
 
 type fieldMapOptions struct {
-	structTags []string
+	structTags    []string
+	rootFieldName string
+	stableIDs     bool
 }
 
 // Option ...
@@ -46,9 +66,25 @@ func WithStructTags(tags ...string) Option {
 	}
 }
 
+// WithRootFieldName changes the name of the root field of mapping structs, default is RootField.
+// A field with the fieldmap:"root" tag is always the root field, regardless of its name.
+func WithRootFieldName(name string) Option {
+	return func(opts *fieldMapOptions) {
+		opts.rootFieldName = name
+	}
+}
+
+// WithStableIDs requires every field to have a stable id, declared with the fieldmap:"id=N" tag
+func WithStableIDs() Option {
+	return func(opts *fieldMapOptions) {
+		opts.stableIDs = true
+	}
+}
+
 func computeOptions(options []Option) fieldMapOptions {
 	opts := fieldMapOptions{
-		structTags: nil,
+		structTags:    nil,
+		rootFieldName: RootField,
 	}
 	for _, fn := range options {
 		fn(&opts)
@@ -58,27 +94,39 @@ func computeOptions(options []Option) fieldMapOptions {
 
 // New ...
 func New[F Field, T MapType[F]](options ...Option) *FieldMap[F, T] {
-	opts := computeOptions(options)
-
-	f := &FieldMap[F, T]{
-		options:    opts,
-		structTags: map[string][]string{},
-	}
+	f := newFieldMap[F, T](options)
 
-	ordinal := int64(0)
 	var info parentInfoData[F]
 
 	var mapping T
 	val := reflect.ValueOf(&mapping)
 	val = val.Elem()
 
-	f.traverse(val, &ordinal, info)
+	f.traverse(val, info)
 
 	f.mapping = mapping
 
 	return f
 }
 
+func newFieldMap[F Field, T MapType[F]](options []Option) *FieldMap[F, T] {
+	return &FieldMap[F, T]{
+		options:    computeOptions(options),
+		structTags: map[string][]string{},
+
+		fullNameIndex: map[string]F{},
+		fullTagIndex:  map[string]map[string]F{},
+
+		mapNodes: map[F]*mapNodeData[F]{},
+		mapKeyOf: map[F]F{},
+
+		stableIDPaths: map[F][]uint32{},
+		stableIDIndex: map[string]F{},
+
+		costs: map[F]int{},
+	}
+}
+
 func (*FieldMap[F, T]) getField(num int64) F {
 	var field F
 	val := reflect.ValueOf(&field).Elem()
@@ -98,17 +146,14 @@ type parentInfoData[F Field] struct {
 	fullFieldName string
 
 	structTags map[string]string
-}
+	attributes Attribute
+	stableID   uint32
 
-func (p parentInfoData[F]) isParentField(index int) bool {
-	return index == 0
+	structTypes []reflect.Type
 }
 
 func (p parentInfoData[F]) computeFullName(currentName string) string {
-	if len(p.fullFieldName) > 0 {
-		return p.fullFieldName + "." + currentName
-	}
-	return currentName
+	return joinFieldName(p.fullFieldName, currentName)
 }
 
 func (f *FieldMap[F, T]) findStructTags(
@@ -132,88 +177,165 @@ func (f *FieldMap[F, T]) findStructTags(
 	return structTags
 }
 
-func (f *FieldMap[F, T]) getRootField(
-	val reflect.Value, parentInfo parentInfoData[F], ordinal *int64,
+// appendField assigns the next ordinal to a new field of the tree
+func (f *FieldMap[F, T]) appendField(
+	parent F, fieldName string, structTags map[string]string, attrs Attribute,
 ) F {
-	var panicStr string
-	if len(parentInfo.fullFieldName) > 0 {
-		panicStr = fmt.Sprintf("missing field %q for field %q", RootField, parentInfo.fullFieldName)
-	} else {
-		panicStr = fmt.Sprintf("missing field %q for root of struct", RootField)
+	field := f.getField(int64(len(f.fields)) + 1)
+
+	f.fields = append(f.fields, field)
+	f.children = append(f.children, nil)
+	f.parentList = append(f.parentList, parent)
+	f.fieldNames = append(f.fieldNames, fieldName)
+	f.attributes = append(f.attributes, attrs)
+	f.stableIDs = append(f.stableIDs, 0)
+
+	for _, tag := range f.options.structTags {
+		f.structTags[tag] = append(f.structTags[tag], structTags[tag])
+	}
+
+	var empty F
+	if parent != empty {
+		index := f.indexOf(parent)
+		f.children[index] = append(f.children[index], field)
+		f.fullNameIndex[f.GetFullFieldName(field)] = field
+
+		for _, tag := range f.options.structTags {
+			index, ok := f.fullTagIndex[tag]
+			if !ok {
+				index = map[string]F{}
+				f.fullTagIndex[tag] = index
+			}
+			index[f.GetFullStructTag(tag, field)] = field
+		}
+	}
+	return field
+}
+
+func (f *FieldMap[F, T]) getRootFieldIndex(
+	fields []structFieldInfo, parentInfo parentInfoData[F],
+) int {
+	rootIndex := -1
+	for i, info := range fields {
+		if !info.options.root {
+			continue
+		}
+		if rootIndex >= 0 {
+			panic(fmt.Sprintf(
+				"multiple root fields %q and %q",
+				parentInfo.computeFullName(fields[rootIndex].field.Name),
+				parentInfo.computeFullName(info.field.Name),
+			))
+		}
+		rootIndex = i
+	}
+	if rootIndex >= 0 {
+		return rootIndex
 	}
 
-	if val.NumField() == 0 {
-		panic(panicStr)
+	for i, info := range fields {
+		if info.field.Name == f.options.rootFieldName {
+			return i
+		}
 	}
 
-	fieldName := val.Type().Field(0).Name
-	if fieldName != RootField {
-		panic(panicStr)
+	if len(parentInfo.fullFieldName) > 0 {
+		panic(fmt.Sprintf("missing field %q for field %q", f.options.rootFieldName, parentInfo.fullFieldName))
 	}
-	return f.getField(*ordinal + 1)
+	panic(fmt.Sprintf("missing field %q for root of struct", f.options.rootFieldName))
 }
 
 func (f *FieldMap[F, T]) handleSingleField(
-	val reflect.Value, i int, parentInfo parentInfoData[F],
-	rootField F, ordinal *int64,
+	info structFieldInfo, parentInfo parentInfoData[F], rootField F,
 ) {
-	field := val.Field(i)
-	fieldType := val.Type().Field(i)
-	fieldName := fieldType.Name
+	fieldName := info.field.Name
 	fullFieldName := parentInfo.computeFullName(fieldName)
 
-	var currentStructTags map[string]string
-	if !parentInfo.isParentField(i) {
-		currentStructTags = f.findStructTags(fieldType, fullFieldName)
+	currentStructTags := f.findStructTags(info.field, fullFieldName)
+	attrs := parentInfo.attributes | info.options.attributes
 
-		if field.Kind() == reflect.Struct {
-			newInfo := parentInfoData[F]{
-				prevRoot: rootField,
+	field := info.value
+	if info.options.leaf {
+		newField := f.appendField(rootField, fieldName, currentStructTags, attrs)
+		f.mustSetStableID(newField, info.options.stableID)
+		if leaf, ok := f.leafValue(field, fullFieldName); ok {
+			leaf.SetInt(int64(newField))
+		}
+		return
+	}
 
-				fieldName:     fieldName,
-				fullFieldName: fullFieldName,
+	if isPointerToStruct(field.Type()) {
+		field = allocatePointer(field)
+	}
 
-				structTags: currentStructTags,
-			}
-			f.traverse(field, ordinal, newInfo)
-			return
+	if field.Type() == f.getMapNodeType() {
+		node := f.traverseMapNode(field, rootField, fieldName, currentStructTags, attrs)
+		f.mustSetStableID(node.Root, info.options.stableID)
+		return
+	}
+
+	if field.Kind() == reflect.Struct {
+		newInfo := parentInfoData[F]{
+			prevRoot: rootField,
+
+			fieldName:     fieldName,
+			fullFieldName: fullFieldName,
+
+			structTags: currentStructTags,
+			attributes: attrs,
+			stableID:   info.options.stableID,
+
+			structTypes: parentInfo.structTypes,
 		}
+		f.traverse(field, newInfo)
+		return
 	}
 
 	if field.Type() != f.getFieldType() {
 		panic(fmt.Sprintf("invalid type for field %q", fullFieldName))
 	}
 
-	*ordinal++
-
-	f.fields = append(f.fields, f.getField(*ordinal))
+	newField := f.appendField(rootField, fieldName, currentStructTags, attrs)
+	f.mustSetStableID(newField, info.options.stableID)
+	field.SetInt(int64(newField))
+}
 
-	if parentInfo.isParentField(i) {
-		f.children = append(f.children, int64(val.NumField()-1))
-		f.parentList = append(f.parentList, parentInfo.prevRoot)
-		f.fieldNames = append(f.fieldNames, parentInfo.fieldName)
+// leafValue returns the value storing the ordinal of a leaf field: the field itself if its type is F,
+// or the root field of type F if the field is a struct, e.g. a reused mapping struct.
+// Other leaf fields, such as value objects, can not store their ordinals, see FindByFullFieldName.
+func (f *FieldMap[F, T]) leafValue(field reflect.Value, fullFieldName string) (reflect.Value, bool) {
+	if isPointerToStruct(field.Type()) {
+		field = allocatePointer(field)
+	}
 
-		for _, tag := range f.options.structTags {
-			f.structTags[tag] = append(f.structTags[tag], parentInfo.structTags[tag])
+	if field.Kind() == reflect.Struct {
+		fields := collectStructFields(field, fullFieldName)
+		rootIndex := -1
+		for i, info := range fields {
+			if info.options.root {
+				rootIndex = i
+				break
+			}
+			if rootIndex < 0 && info.field.Name == f.options.rootFieldName {
+				rootIndex = i
+			}
 		}
-	} else {
-		f.children = append(f.children, 0)
-		f.parentList = append(f.parentList, rootField)
-		f.fieldNames = append(f.fieldNames, fieldName)
-
-		for _, tag := range f.options.structTags {
-			f.structTags[tag] = append(f.structTags[tag], currentStructTags[tag])
+		if rootIndex < 0 {
+			return reflect.Value{}, false
 		}
+		field = fields[rootIndex].value
 	}
-	field.SetInt(*ordinal)
-}
 
-func (f *FieldMap[F, T]) checkGetRootImpl() {
-	var mapping T
-	rootVal := reflect.ValueOf(&mapping).Elem().Field(0)
+	if field.Type() != f.getFieldType() {
+		return reflect.Value{}, false
+	}
+	return field, true
+}
 
+func (f *FieldMap[F, T]) checkGetRootImpl(val reflect.Value, rootVal reflect.Value) {
 	panicIfNotEq := func(num int64) {
 		rootVal.SetInt(num)
+		mapping := val.Interface().(T)
 		if mapping.GetRoot() != f.getField(num) {
 			panic("invalid GetRoot implementation")
 		}
@@ -226,18 +348,48 @@ func (f *FieldMap[F, T]) checkGetRootImpl() {
 }
 
 func (f *FieldMap[F, T]) traverse(
-	val reflect.Value, ordinal *int64, parentInfo parentInfoData[F],
+	val reflect.Value, parentInfo parentInfoData[F],
 ) {
-	rootField := f.getRootField(val, parentInfo, ordinal)
+	for _, structType := range parentInfo.structTypes {
+		if structType == val.Type() {
+			panic(fmt.Sprintf("recursive struct type for field %q", parentInfo.fullFieldName))
+		}
+	}
+	parentInfo.structTypes = append(parentInfo.structTypes, val.Type())
+
+	fields := collectStructFields(val, parentInfo.fullFieldName)
+
+	rootIndex := f.getRootFieldIndex(fields, parentInfo)
+	rootInfo := fields[rootIndex]
+	rootVal := rootInfo.value
+	if rootVal.Type() != f.getFieldType() {
+		panic(fmt.Sprintf("invalid type for field %q", parentInfo.computeFullName(rootInfo.field.Name)))
+	}
 
 	var empty F
 	if parentInfo.prevRoot == empty {
-		f.checkGetRootImpl()
+		f.checkGetRootImpl(val, rootVal)
+	}
+
+	parentInfo.attributes |= rootInfo.options.attributes
+
+	rootField := f.appendField(
+		parentInfo.prevRoot, parentInfo.fieldName,
+		parentInfo.structTags, parentInfo.attributes,
+	)
+	rootVal.SetInt(int64(rootField))
+
+	if parentInfo.prevRoot == empty {
 		f.structRoot = rootField
+	} else {
+		f.mustSetStableID(rootField, parentInfo.stableID)
 	}
 
-	for i := 0; i < val.NumField(); i++ {
-		f.handleSingleField(val, i, parentInfo, rootField, ordinal)
+	for i, info := range fields {
+		if i == rootIndex {
+			continue
+		}
+		f.handleSingleField(info, parentInfo, rootField)
 	}
 }
 
@@ -252,14 +404,12 @@ func (*FieldMap[F, T]) indexOf(field F) int64 {
 
 // IsStruct ...
 func (f *FieldMap[F, T]) IsStruct(field F) bool {
-	index := f.indexOf(field)
-	return f.children[index] > 0
+	return len(f.children[f.indexOf(field)]) > 0
 }
 
-// ChildrenOf ...
+// ChildrenOf returns a copy of the direct children of a struct root
 func (f *FieldMap[F, T]) ChildrenOf(field F) []F {
-	index := f.indexOf(field)
-	return f.fields[index+1 : index+1+f.children[index]]
+	return append([]F(nil), f.children[f.indexOf(field)]...)
 }
 
 // ParentOf ...
@@ -289,6 +439,9 @@ func (f *FieldMap[F, T]) GetFieldName(field F) string {
 // GetFullFieldName ...
 func (f *FieldMap[F, T]) GetFullFieldName(field F) string {
 	fullName := ""
+	if field == f.structRoot {
+		return fullName
+	}
 	for {
 		name := f.GetFieldName(field)
 		if len(fullName) > 0 {
@@ -304,6 +457,19 @@ func (f *FieldMap[F, T]) GetFullFieldName(field F) string {
 	}
 }
 
+// FindByFullFieldName returns the field with the full field name, e.g. "Seller.Attr.Code".
+// Useful for leaf fields that can not store their ordinals, such as value objects with fieldmap:"leaf" tag.
+func (f *FieldMap[F, T]) FindByFullFieldName(fullName string) (F, bool) {
+	field, ok := f.fullNameIndex[fullName]
+	return field, ok
+}
+
+// FindByFullStructTag returns the field with the full struct tag, e.g. "seller.attr.code"
+func (f *FieldMap[F, T]) FindByFullStructTag(tag string, fullTag string) (F, bool) {
+	field, ok := f.fullTagIndex[tag][fullTag]
+	return field, ok
+}
+
 // GetStructTag ...
 func (f *FieldMap[F, T]) GetStructTag(tag string, field F) string {
 	return f.structTags[tag][f.indexOf(field)]
@@ -312,6 +478,9 @@ func (f *FieldMap[F, T]) GetStructTag(tag string, field F) string {
 // GetFullStructTag ...
 func (f *FieldMap[F, T]) GetFullStructTag(tag string, field F) string {
 	fullTag := ""
+	if field == f.structRoot {
+		return fullTag
+	}
 	for {
 		tagName := f.GetStructTag(tag, field)
 		if len(fullTag) > 0 {
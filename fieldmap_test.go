@@ -79,6 +79,11 @@ func TestFieldMap__GetMapping(t *testing.T) {
 		assert.Equal(t, false, fm.IsStruct(p.Seller.ID))
 
 		assert.Equal(t, []field{5, 6, 7, 8}, fm.ChildrenOf(p.Seller.Root))
+		assert.Equal(t, []field{2, 3, 4, 11}, fm.ChildrenOf(p.Root))
+
+		children := fm.ChildrenOf(p.Root)
+		children[0] = 100
+		assert.Equal(t, []field{2, 3, 4, 11}, fm.ChildrenOf(p.Root))
 
 		assert.Equal(t, p.Seller.Root, fm.ParentOf(p.Seller.ID))
 		assert.Equal(t, p.Seller.Root, fm.ParentOf(p.Seller.Name))
@@ -275,3 +280,93 @@ func TestFieldMap__Errors(t *testing.T) {
 		})
 	})
 }
+
+type selfSellerData struct {
+	Self field
+
+	ID   field
+	Name field
+}
+
+type selfProductData struct {
+	Sku    field
+	Self   field
+	Seller selfSellerData
+}
+
+func (d selfProductData) GetRoot() field { return d.Self }
+
+type taggedSellerData struct {
+	Root field
+	Key  field `fieldmap:"root"`
+}
+
+type taggedProductData struct {
+	Root   field
+	Seller taggedSellerData
+	ID     field `fieldmap:"root"`
+}
+
+func (d taggedProductData) GetRoot() field { return d.ID }
+
+type multipleRootData struct {
+	ID  field `fieldmap:"root"`
+	Key field `fieldmap:"root"`
+}
+
+func (d multipleRootData) GetRoot() field { return d.ID }
+
+type taggedRootWithInvalidGetRoot struct {
+	Root field
+	ID   field `fieldmap:"root"`
+}
+
+func (d taggedRootWithInvalidGetRoot) GetRoot() field { return d.Root }
+
+func TestFieldMap__RootField(t *testing.T) {
+	t.Run("with root field name", func(t *testing.T) {
+		fm := New[field, selfProductData](WithRootFieldName("Self"))
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, field(1), p.Self)
+		assert.Equal(t, field(2), p.Sku)
+		assert.Equal(t, field(3), p.Seller.Self)
+		assert.Equal(t, field(4), p.Seller.ID)
+		assert.Equal(t, field(5), p.Seller.Name)
+
+		assert.Equal(t, "Seller.Name", fm.GetFullFieldName(p.Seller.Name))
+	})
+
+	t.Run("with root field tag", func(t *testing.T) {
+		fm := New[field, taggedProductData]()
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, field(1), p.ID)
+		assert.Equal(t, field(2), p.Root)
+		assert.Equal(t, field(3), p.Seller.Key)
+		assert.Equal(t, field(4), p.Seller.Root)
+
+		assert.Equal(t, []field{2, 3}, fm.ChildrenOf(p.ID))
+		assert.Equal(t, "Seller.Root", fm.GetFullFieldName(p.Seller.Root))
+	})
+
+	t.Run("missing root field with name", func(t *testing.T) {
+		assert.PanicsWithValue(t, `missing field "Self" for root of struct`, func() {
+			New[field, productData](WithRootFieldName("Self"))
+		})
+	})
+
+	t.Run("multiple root fields", func(t *testing.T) {
+		assert.PanicsWithValue(t, `multiple root fields "ID" and "Key"`, func() {
+			New[field, multipleRootData]()
+		})
+	})
+
+	t.Run("invalid GetRoot with root field tag", func(t *testing.T) {
+		assert.PanicsWithValue(t, `invalid GetRoot implementation`, func() {
+			New[field, taggedRootWithInvalidGetRoot]()
+		})
+	})
+}
@@ -0,0 +1,15 @@
+// Command fieldmapcheck reports mistakes in mapping structs of fieldmap.New at compile time.
+//
+// Usage:
+//
+//	fieldmapcheck ./...
+package main
+
+import (
+	"github.com/QuangTung97/fieldmap/fieldmapcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(fieldmapcheck.Analyzer)
+}
@@ -0,0 +1,578 @@
+// Package fieldmapcheck defines an Analyzer that reports mistakes in mapping structs of fieldmap.New,
+// which would otherwise only be found as panics at runtime.
+package fieldmapcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const fieldmapPath = "github.com/QuangTung97/fieldmap"
+
+const (
+	rootFieldName = "Root"
+	tagName       = "fieldmap"
+
+	// dataComment is emitted by fieldmap-gen before mapping structs, followed by the name of the data struct
+	dataComment = "//fieldmap:data"
+)
+
+// Analyzer checks the mapping structs of fieldmap.New instantiations:
+// the root field, the GetRoot implementation, types of fields and struct tags of WithStructTags.
+// It also checks that mapping structs annotated with //fieldmap:data have the same fields as their data structs.
+var Analyzer = &analysis.Analyzer{
+	Name:     "fieldmapcheck",
+	Doc:      "check mapping structs of fieldmap.New and their paired data structs",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+type checker struct {
+	pass *analysis.Pass
+
+	files    map[*token.File]bool
+	getRoots map[*types.TypeName]*ast.FuncDecl
+	reported map[string]bool
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	c := &checker{
+		pass:     pass,
+		files:    map[*token.File]bool{},
+		getRoots: map[*types.TypeName]*ast.FuncDecl{},
+		reported: map[string]bool{},
+	}
+	for _, file := range pass.Files {
+		c.files[pass.Fset.File(file.Pos())] = true
+	}
+
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	insp.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		decl := n.(*ast.FuncDecl)
+		if decl.Recv == nil || decl.Name.Name != "GetRoot" || len(decl.Recv.List) != 1 {
+			return
+		}
+		if named := namedOf(pass.TypesInfo.TypeOf(decl.Recv.List[0].Type)); named != nil {
+			c.getRoots[named.Obj()] = decl
+		}
+	})
+
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		c.checkNewCall(n.(*ast.CallExpr))
+	})
+
+	insp.Preorder([]ast.Node{(*ast.GenDecl)(nil)}, func(n ast.Node) {
+		decl := n.(*ast.GenDecl)
+		if decl.Tok != token.TYPE {
+			return
+		}
+		for _, spec := range decl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			doc := typeSpec.Doc
+			if doc == nil && len(decl.Specs) == 1 {
+				doc = decl.Doc
+			}
+			if dataName, ok := findDataComment(doc); ok {
+				c.checkDrift(typeSpec, dataName)
+			}
+		}
+	})
+
+	return nil, nil
+}
+
+func (c *checker) report(pos token.Pos, fallback token.Pos, format string, args ...any) {
+	if !c.files[c.pass.Fset.File(pos)] {
+		pos = fallback
+	}
+
+	msg := fmt.Sprintf(format, args...)
+	key := fmt.Sprintf("%d:%s", pos, msg)
+	if c.reported[key] {
+		return
+	}
+	c.reported[key] = true
+
+	c.pass.Reportf(pos, "%s", msg)
+}
+
+func namedOf(t types.Type) *types.Named {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+func isFieldmapObject(obj types.Object, name string) bool {
+	return obj != nil && obj.Pkg() != nil && obj.Pkg().Path() == fieldmapPath && obj.Name() == name
+}
+
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	switch e := ast.Unparen(fun).(type) {
+	case *ast.IndexExpr:
+		fun = e.X
+	case *ast.IndexListExpr:
+		fun = e.X
+	}
+
+	switch e := ast.Unparen(fun).(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	default:
+		return nil
+	}
+}
+
+// newCall is an instantiation of fieldmap.New with its options
+type newCall struct {
+	pos token.Pos
+
+	fieldType types.Type
+	rootName  string
+
+	// structTags is nil if the tags are not constants
+	structTags []string
+}
+
+func (c *checker) checkNewCall(call *ast.CallExpr) {
+	ident := calleeIdent(call.Fun)
+	if ident == nil || !isFieldmapObject(c.pass.TypesInfo.Uses[ident], "New") {
+		return
+	}
+	inst, ok := c.pass.TypesInfo.Instances[ident]
+	if !ok || inst.TypeArgs.Len() != 2 {
+		return
+	}
+
+	info := newCall{
+		pos:        call.Pos(),
+		fieldType:  inst.TypeArgs.At(0),
+		rootName:   rootFieldName,
+		structTags: []string{},
+	}
+	for _, arg := range call.Args {
+		c.parseOption(arg, &info)
+	}
+
+	mappingType := inst.TypeArgs.At(1)
+	st, ok := mappingType.Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+	c.checkStruct(info, mappingType, st, "", []types.Type{mappingType})
+}
+
+func (c *checker) parseOption(arg ast.Expr, info *newCall) {
+	call, ok := ast.Unparen(arg).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	ident := calleeIdent(call.Fun)
+	if ident == nil {
+		return
+	}
+
+	obj := c.pass.TypesInfo.Uses[ident]
+	switch {
+	case isFieldmapObject(obj, "WithStructTags"):
+		info.structTags = nil
+		if call.Ellipsis.IsValid() {
+			return
+		}
+		tags := []string{}
+		for _, tagArg := range call.Args {
+			value, ok := c.constString(tagArg)
+			if !ok {
+				return
+			}
+			tags = append(tags, value)
+		}
+		info.structTags = tags
+
+	case isFieldmapObject(obj, "WithRootFieldName"):
+		if len(call.Args) == 1 {
+			if value, ok := c.constString(call.Args[0]); ok {
+				info.rootName = value
+			}
+		}
+	}
+}
+
+func (c *checker) constString(expr ast.Expr) (string, bool) {
+	tv, ok := c.pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+type structField struct {
+	v       *types.Var
+	tag     reflect.StructTag
+	options []string
+}
+
+func (f structField) hasOption(option string) bool {
+	for _, o := range f.options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFields returns the fields of a struct, ambiguous fields are not included
+func collectFields(st *types.Struct) []structField {
+	fields, _ := resolveFields(st)
+	return fields
+}
+
+// resolveFields promotes fields of embedded structs with the same rules as collectStructFields of fieldmap:
+// a field at a shallower depth hides the fields with the same name at deeper depths,
+// and fields with the same name at the same depth are ambiguous, the first ambiguous name is returned
+func resolveFields(st *types.Struct) ([]structField, string) {
+	all := collectFieldsOfDepth(st, 0, nil)
+
+	minDepth := map[string]int{}
+	count := map[string]int{}
+	for _, f := range all {
+		name := f.v.Name()
+		depth, existed := minDepth[name]
+		if !existed || f.depth < depth {
+			minDepth[name] = f.depth
+			count[name] = 1
+		} else if f.depth == depth {
+			count[name]++
+		}
+	}
+
+	var result []structField
+	var ambiguous string
+	for _, f := range all {
+		name := f.v.Name()
+		if f.depth != minDepth[name] {
+			continue
+		}
+		if count[name] > 1 {
+			if len(ambiguous) == 0 {
+				ambiguous = name
+			}
+			continue
+		}
+		result = append(result, f.structField)
+	}
+	return result, ambiguous
+}
+
+type depthField struct {
+	structField
+	depth int
+}
+
+func collectFieldsOfDepth(st *types.Struct, depth int, embeddedTypes []*types.Struct) []depthField {
+	var result []depthField
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+		f := structField{v: v, tag: tag, options: splitOptions(tag.Get(tagName))}
+		if len(f.options) == 1 && f.options[0] == "-" {
+			continue
+		}
+
+		if v.Embedded() && !f.hasOption("leaf") {
+			if embedded, ok := derefPointer(v.Type()).Underlying().(*types.Struct); ok {
+				if !containsStruct(embeddedTypes, embedded) {
+					result = append(result, collectFieldsOfDepth(
+						embedded, depth+1, append(embeddedTypes, embedded),
+					)...)
+				}
+				continue
+			}
+		}
+		if !v.Exported() {
+			continue
+		}
+		result = append(result, depthField{structField: f, depth: depth})
+	}
+	return result
+}
+
+func containsStruct(list []*types.Struct, st *types.Struct) bool {
+	for _, e := range list {
+		if types.Identical(e, st) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitOptions(tag string) []string {
+	if len(tag) == 0 {
+		return nil
+	}
+	options := strings.Split(tag, ",")
+	for i := range options {
+		options[i] = strings.TrimSpace(options[i])
+	}
+	return options
+}
+
+func derefPointer(t types.Type) types.Type {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		return ptr.Elem()
+	}
+	return t
+}
+
+func joinFieldName(parent string, name string) string {
+	if len(parent) == 0 {
+		return name
+	}
+	return parent + "." + name
+}
+
+func findRootField(fields []structField, rootName string) (structField, bool) {
+	for _, f := range fields {
+		if f.hasOption("root") {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if f.v.Name() == rootName {
+			return f, true
+		}
+	}
+	return structField{}, false
+}
+
+func (c *checker) structPos(t types.Type, fallback token.Pos) token.Pos {
+	if named := namedOf(t); named != nil {
+		return named.Obj().Pos()
+	}
+	return fallback
+}
+
+func (c *checker) checkStruct(
+	info newCall, structType types.Type, st *types.Struct, fullFieldName string, structTypes []types.Type,
+) {
+	fields, ambiguous := resolveFields(st)
+	if len(ambiguous) > 0 {
+		c.report(c.structPos(structType, info.pos), info.pos, "ambiguous field %q", joinFieldName(fullFieldName, ambiguous))
+		return
+	}
+
+	var tagged []structField
+	for _, f := range fields {
+		if f.hasOption("root") {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(tagged) > 1 {
+		c.report(tagged[1].v.Pos(), info.pos, "multiple root fields %q and %q",
+			joinFieldName(fullFieldName, tagged[0].v.Name()), joinFieldName(fullFieldName, tagged[1].v.Name()))
+		return
+	}
+
+	root, ok := findRootField(fields, info.rootName)
+	if !ok {
+		pos := c.structPos(structType, info.pos)
+		if len(fullFieldName) > 0 {
+			c.report(pos, info.pos, "missing field %q for field %q", info.rootName, fullFieldName)
+		} else {
+			c.report(pos, info.pos, "missing field %q for root of struct", info.rootName)
+		}
+		return
+	}
+
+	if !types.Identical(root.v.Type(), info.fieldType) {
+		c.report(root.v.Pos(), info.pos, "invalid type for field %q", joinFieldName(fullFieldName, root.v.Name()))
+	} else if len(fullFieldName) == 0 {
+		c.checkGetRoot(info, structType, root)
+	}
+
+	for _, f := range fields {
+		if f.v == root.v {
+			continue
+		}
+		c.checkField(info, f, joinFieldName(fullFieldName, f.v.Name()), structTypes)
+	}
+}
+
+func (c *checker) checkField(info newCall, f structField, fullFieldName string, structTypes []types.Type) {
+	for _, tag := range info.structTags {
+		if len(f.tag.Get(tag)) == 0 {
+			c.report(f.v.Pos(), info.pos, "missing struct tag %q for field %q", tag, fullFieldName)
+		}
+	}
+
+	if f.hasOption("leaf") {
+		return
+	}
+
+	fieldType := f.v.Type()
+	if ptr, ok := fieldType.Underlying().(*types.Pointer); ok {
+		if _, ok := ptr.Elem().Underlying().(*types.Struct); ok {
+			fieldType = ptr.Elem()
+		}
+	}
+
+	if named := namedOf(fieldType); named != nil && isFieldmapObject(named.Obj(), "MapNode") {
+		args := named.TypeArgs()
+		if args.Len() != 1 || !types.Identical(args.At(0), info.fieldType) {
+			c.report(f.v.Pos(), info.pos, "invalid type for field %q", fullFieldName)
+		}
+		return
+	}
+
+	if st, ok := fieldType.Underlying().(*types.Struct); ok {
+		for _, t := range structTypes {
+			if types.Identical(t, fieldType) {
+				c.report(f.v.Pos(), info.pos, "recursive struct type for field %q", fullFieldName)
+				return
+			}
+		}
+		c.checkStruct(info, fieldType, st, fullFieldName, append(structTypes, fieldType))
+		return
+	}
+
+	if !types.Identical(fieldType, info.fieldType) {
+		c.report(f.v.Pos(), info.pos, "invalid type for field %q", fullFieldName)
+	}
+}
+
+// checkGetRoot checks that GetRoot of the mapping struct only returns the root field
+func (c *checker) checkGetRoot(info newCall, structType types.Type, root structField) {
+	named := namedOf(structType)
+	if named == nil {
+		return
+	}
+	decl, ok := c.getRoots[named.Obj()]
+	if !ok {
+		return
+	}
+
+	if !c.returnsRootField(decl, root) {
+		c.report(decl.Name.Pos(), info.pos,
+			"GetRoot of %s must return the field %q", named.Obj().Name(), root.v.Name())
+	}
+}
+
+func (c *checker) returnsRootField(decl *ast.FuncDecl, root structField) bool {
+	if decl.Body == nil || len(decl.Body.List) != 1 || len(decl.Recv.List[0].Names) != 1 {
+		return false
+	}
+	ret, ok := decl.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return false
+	}
+	sel, ok := ast.Unparen(ret.Results[0]).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	recv, ok := ast.Unparen(sel.X).(*ast.Ident)
+	if !ok || c.pass.TypesInfo.Uses[recv] != c.pass.TypesInfo.Defs[decl.Recv.List[0].Names[0]] {
+		return false
+	}
+
+	selection, ok := c.pass.TypesInfo.Selections[sel]
+	return ok && selection.Obj() == root.v
+}
+
+func findDataComment(doc *ast.CommentGroup) (string, bool) {
+	if doc == nil {
+		return "", false
+	}
+	for _, comment := range doc.List {
+		if strings.HasPrefix(comment.Text, dataComment+" ") {
+			return strings.TrimSpace(strings.TrimPrefix(comment.Text, dataComment)), true
+		}
+	}
+	return "", false
+}
+
+// lookupDataStruct finds the data struct of a name such as "Seller" or "Seller.Info"
+func (c *checker) lookupDataStruct(dataName string) (*types.Struct, bool) {
+	parts := strings.Split(dataName, ".")
+
+	obj, ok := c.pass.Pkg.Scope().Lookup(parts[0]).(*types.TypeName)
+	if !ok {
+		return nil, false
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, false
+	}
+
+	for _, name := range parts[1:] {
+		found := false
+		for _, f := range collectFields(st) {
+			if f.v.Name() != name {
+				continue
+			}
+			st, found = derefPointer(f.v.Type()).Underlying().(*types.Struct)
+			break
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return st, true
+}
+
+// checkDrift compares the fields of a mapping struct with its paired data struct
+func (c *checker) checkDrift(typeSpec *ast.TypeSpec, dataName string) {
+	obj, ok := c.pass.TypesInfo.Defs[typeSpec.Name].(*types.TypeName)
+	if !ok {
+		return
+	}
+	mappingStruct, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+
+	dataStruct, ok := c.lookupDataStruct(dataName)
+	if !ok {
+		c.report(typeSpec.Name.Pos(), typeSpec.Name.Pos(), "data struct %q of %s not found", dataName, obj.Name())
+		return
+	}
+
+	mappingFields := collectFields(mappingStruct)
+	root, hasRoot := findRootField(mappingFields, rootFieldName)
+
+	dataFieldSet := map[string]bool{}
+	for _, f := range collectFields(dataStruct) {
+		dataFieldSet[f.v.Name()] = true
+	}
+
+	mappingFieldSet := map[string]bool{}
+	for _, f := range mappingFields {
+		if hasRoot && f.v == root.v {
+			continue
+		}
+		mappingFieldSet[f.v.Name()] = true
+		if !dataFieldSet[f.v.Name()] {
+			c.report(f.v.Pos(), typeSpec.Name.Pos(), "field %q of %s is not in data struct %q",
+				f.v.Name(), obj.Name(), dataName)
+		}
+	}
+
+	for _, f := range collectFields(dataStruct) {
+		if !mappingFieldSet[f.v.Name()] {
+			c.report(typeSpec.Name.Pos(), typeSpec.Name.Pos(), "missing field %q of data struct %q in %s",
+				f.v.Name(), dataName, obj.Name())
+		}
+	}
+}
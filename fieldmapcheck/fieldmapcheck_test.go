@@ -0,0 +1,16 @@
+package fieldmapcheck
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a", "drift")
+}
+
+// TestAnalyzer_Example runs against the real fieldmap module instead of the stub in testdata
+func TestAnalyzer_Example(t *testing.T) {
+	analysistest.Run(t, "..", Analyzer, "./internal/example")
+}
@@ -0,0 +1,13 @@
+// A separate module, so that the fieldmap module does not depend on golang.org/x/tools.
+// The go version is the minimum required by golang.org/x/tools,
+// older versions of x/tools can not load packages compiled by recent Go toolchains.
+module github.com/QuangTung97/fieldmap/fieldmapcheck
+
+go 1.26.0
+
+require golang.org/x/tools v0.51.0
+
+require (
+	golang.org/x/mod v0.41.0 // indirect
+	golang.org/x/sync v0.23.0 // indirect
+)
@@ -0,0 +1,128 @@
+package a
+
+import "github.com/QuangTung97/fieldmap"
+
+type field int
+type otherField int
+
+type sellerMapping struct {
+	Root field
+	ID   field `json:"id"`
+	Name field // want `missing struct tag "json" for field "Seller.Name"`
+}
+
+type productMapping struct {
+	Root   field
+	Sku    field                   `json:"sku"`
+	Count  int                     `json:"count"` // want `invalid type for field "Count"`
+	Money  moneyData               `json:"money" fieldmap:"leaf"`
+	Price  field                   `json:"price" fieldmap:"leaf"`
+	Info   sellerMapping           `json:"info" fieldmap:"leaf"`
+	Seller sellerMapping           `json:"seller"`
+	Attrs  fieldmap.MapNode[field] `json:"attrs"`
+	Other  fieldmap.MapNode[int]   `json:"other"` // want `invalid type for field "Other"`
+	Skip   string                  `fieldmap:"-"`
+
+	internal string
+}
+
+type moneyData struct {
+	Amount int64
+}
+
+func (m productMapping) GetRoot() field { return m.Root }
+
+type missingRoot struct { // want `missing field "Root" for root of struct`
+	Sku field
+}
+
+func (m missingRoot) GetRoot() field { return m.Sku }
+
+type wrongGetRoot struct {
+	Root field
+	Sku  field
+}
+
+func (m wrongGetRoot) GetRoot() field { // want `GetRoot of wrongGetRoot must return the field "Root"`
+	return m.Sku
+}
+
+type customRoot struct {
+	Base otherField `fieldmap:"root"`
+	Info infoMapping
+}
+
+type infoMapping struct { // want `missing field "Base" for field "Info"`
+	Root otherField
+}
+
+func (m customRoot) GetRoot() otherField { return m.Base }
+
+type recursiveMapping struct {
+	Root  field
+	Child *recursiveMapping // want `recursive struct type for field "Child"`
+}
+
+func (m recursiveMapping) GetRoot() field { return m.Root }
+
+type multipleRoot struct {
+	ID  field `fieldmap:"root"`
+	Key field `fieldmap:"root"` // want `multiple root fields "ID" and "Key"`
+}
+
+func (m multipleRoot) GetRoot() field { return m.ID }
+
+type embeddedBase struct {
+	Root field
+	ID   field
+}
+
+type embeddedMapping struct {
+	embeddedBase
+	Name field
+}
+
+type auditA struct {
+	CreatedAt field
+}
+
+type auditB struct {
+	CreatedAt field
+	UpdatedAt field
+}
+
+type ambiguousMapping struct { // want `ambiguous field "CreatedAt"`
+	Root field
+	auditA
+	auditB
+}
+
+func (m ambiguousMapping) GetRoot() field { return m.Root }
+
+type shadowedMapping struct {
+	Root      field
+	CreatedAt field
+	auditA
+	auditB
+}
+
+func (m shadowedMapping) GetRoot() field { return m.Root }
+
+func use() {
+	_ = fieldmap.New[field, productMapping](fieldmap.WithStructTags("json"))
+	_ = fieldmap.New[field, missingRoot]()
+	_ = fieldmap.New[field, wrongGetRoot]()
+	_ = fieldmap.New[otherField, customRoot](fieldmap.WithRootFieldName("Base"))
+	_ = fieldmap.New[field, recursiveMapping]()
+	_ = fieldmap.New[field, embeddedMapping]()
+	_ = fieldmap.New[field, multipleRoot]()
+	_ = fieldmap.New[field, ambiguousMapping]()
+	_ = fieldmap.New[field, shadowedMapping]()
+
+	tags := []string{"json"}
+	_ = fieldmap.New[field, sellerMapping](fieldmap.WithStructTags(tags...))
+}
+
+func (m embeddedMapping) GetRoot() field { return m.Root }
+
+func (m sellerMapping) GetRoot() field { return m.Root }
@@ -0,0 +1,46 @@
+package drift
+
+type field int
+
+type Product struct {
+	Sku     string
+	Name    string
+	Price   int64
+	Seller  *Seller
+	Ignored string `fieldmap:"-"`
+}
+
+type Seller struct {
+	ID   int64
+	Info struct {
+		Logo string
+	}
+}
+
+//fieldmap:data Product
+type ProductMapping struct { // want `missing field "Price" of data struct "Product" in ProductMapping`
+	Root field
+
+	Sku    field
+	Name   field
+	Seller SellerMapping
+	Body   field // want `field "Body" of ProductMapping is not in data struct "Product"`
+}
+
+//fieldmap:data Seller
+type SellerMapping struct {
+	Root field
+
+	ID   field
+	Info SellerInfoMapping
+}
+
+//fieldmap:data Seller.Info
+type SellerInfoMapping struct { // want `missing field "Logo" of data struct "Seller.Info" in SellerInfoMapping`
+	Root field
+}
+
+//fieldmap:data Unknown
+type UnknownMapping struct { // want `data struct "Unknown" of UnknownMapping not found`
+	Root field
+}
@@ -0,0 +1,24 @@
+package fieldmap
+
+type Field interface {
+	~int | ~int64
+}
+
+type MapType[F Field] interface {
+	GetRoot() F
+}
+
+type Option func()
+
+func WithStructTags(tags ...string) Option { return nil }
+
+func WithRootFieldName(name string) Option { return nil }
+
+type FieldMap[F Field, T MapType[F]] struct{}
+
+func New[F Field, T MapType[F]](options ...Option) *FieldMap[F, T] { return nil }
+
+type MapNode[F Field] struct {
+	Root F
+	Any  F
+}
@@ -0,0 +1,356 @@
+package fieldmap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldSet is a set of fields bound to a FieldMap, for sending or storing lists of changed fields
+type FieldSet[F Field, T MapType[F]] struct {
+	fm      *FieldMap[F, T]
+	options fieldSetOptions
+
+	fields []F
+}
+
+var _ encoding.BinaryMarshaler = &FieldSet[int, DynamicMapping[int]]{}
+var _ encoding.BinaryUnmarshaler = &FieldSet[int, DynamicMapping[int]]{}
+var _ encoding.TextMarshaler = &FieldSet[int, DynamicMapping[int]]{}
+var _ encoding.TextUnmarshaler = &FieldSet[int, DynamicMapping[int]]{}
+var _ json.Marshaler = &FieldSet[int, DynamicMapping[int]]{}
+var _ json.Unmarshaler = &FieldSet[int, DynamicMapping[int]]{}
+
+type fieldSetOptions struct {
+	fingerprint bool
+	pathTag     string
+}
+
+// FieldSetOption ...
+type FieldSetOption func(opts *fieldSetOptions)
+
+// WithFingerprint adds the schema fingerprint to the binary encoding.
+// Decoding then fails if the data was encoded with a different version of the FieldMap,
+// or with keys registered in a different order, or if the data does not contain a fingerprint.
+func WithFingerprint() FieldSetOption {
+	return func(opts *fieldSetOptions) {
+		opts.fingerprint = true
+	}
+}
+
+// WithPathTag specifies the struct tag used for paths in JSON and text encodings,
+// e.g. "seller.attr.code" with the json tag. Default is full field names, e.g. "Seller.Attr.Code".
+func WithPathTag(tag string) FieldSetOption {
+	return func(opts *fieldSetOptions) {
+		opts.pathTag = tag
+	}
+}
+
+// NewFieldSet creates a FieldSet, duplicated fields are removed
+func NewFieldSet[F Field, T MapType[F]](
+	fm *FieldMap[F, T], fields []F, options ...FieldSetOption,
+) *FieldSet[F, T] {
+	s := &FieldSet[F, T]{
+		fm: fm,
+	}
+	for _, fn := range options {
+		fn(&s.options)
+	}
+	s.setFields(fields)
+	return s
+}
+
+func (s *FieldSet[F, T]) setFields(fields []F) {
+	sorted := make([]F, len(fields))
+	copy(sorted, fields)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	s.fields = sorted[:0]
+	for i, field := range sorted {
+		if i > 0 && field == sorted[i-1] {
+			continue
+		}
+		s.fields = append(s.fields, field)
+	}
+}
+
+// Fields returns the fields in ordinal order
+func (s *FieldSet[F, T]) Fields() []F {
+	return s.fields
+}
+
+const (
+	fieldSetVersion = 1
+
+	fieldSetFlagFingerprint = 1 << 0
+	fieldSetFlagBitmap      = 1 << 1
+
+	fieldSetFingerprintSize = 8
+)
+
+var (
+	// ErrFieldSetFingerprintMismatch is returned when decoding a FieldSet encoded with a different schema
+	ErrFieldSetFingerprintMismatch = errors.New("fieldmap: field set fingerprint mismatch")
+
+	// ErrFieldSetInvalidData is returned when decoding malformed data
+	ErrFieldSetInvalidData = errors.New("fieldmap: invalid field set data")
+
+	// ErrFieldSetUnknownPath is returned when decoding a path not found in the FieldMap
+	ErrFieldSetUnknownPath = errors.New("fieldmap: unknown field path")
+)
+
+func (s *FieldSet[F, T]) fingerprintPrefix() []byte {
+	data, err := hex.DecodeString(s.fm.ordinalFingerprint()[:2*fieldSetFingerprintSize])
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func (s *FieldSet[F, T]) checkOrdinal(ordinal int64) error {
+	if ordinal < 1 || ordinal > int64(len(s.fm.fields)) {
+		return fmt.Errorf("%w: invalid field %d", ErrFieldSetInvalidData, ordinal)
+	}
+	return nil
+}
+
+// MarshalBinary encodes the set as a version byte, a flags byte, an optional fingerprint,
+// then either a bitmap or a list of delta-varints, whichever is smaller
+func (s *FieldSet[F, T]) MarshalBinary() ([]byte, error) {
+	var flags byte
+	header := []byte{fieldSetVersion, 0}
+	if s.options.fingerprint {
+		flags |= fieldSetFlagFingerprint
+		header = append(header, s.fingerprintPrefix()...)
+	}
+
+	list := appendUvarint(nil, uint64(len(s.fields)))
+	var bitmap []byte
+
+	prev := int64(0)
+	for _, field := range s.fields {
+		ordinal := int64(field)
+		if err := s.checkOrdinal(ordinal); err != nil {
+			return nil, err
+		}
+
+		list = appendUvarint(list, uint64(ordinal-prev))
+		prev = ordinal
+
+		index := ordinal - 1
+		for int64(len(bitmap)) <= index/8 {
+			bitmap = append(bitmap, 0)
+		}
+		bitmap[index/8] |= 1 << (index % 8)
+	}
+
+	payload := list
+	if len(bitmap) < len(list) {
+		flags |= fieldSetFlagBitmap
+		payload = bitmap
+	}
+
+	header[1] = flags
+	return append(header, payload...), nil
+}
+
+// UnmarshalBinary decodes data encoded by MarshalBinary
+func (s *FieldSet[F, T]) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return fmt.Errorf("%w: missing header", ErrFieldSetInvalidData)
+	}
+	if data[0] != fieldSetVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrFieldSetInvalidData, data[0])
+	}
+
+	flags := data[1]
+	data = data[2:]
+
+	if flags&fieldSetFlagFingerprint != 0 {
+		if len(data) < fieldSetFingerprintSize {
+			return fmt.Errorf("%w: missing fingerprint", ErrFieldSetInvalidData)
+		}
+		if !bytes.Equal(data[:fieldSetFingerprintSize], s.fingerprintPrefix()) {
+			return ErrFieldSetFingerprintMismatch
+		}
+		data = data[fieldSetFingerprintSize:]
+	} else if s.options.fingerprint {
+		return fmt.Errorf("%w: missing fingerprint", ErrFieldSetInvalidData)
+	}
+
+	var ordinals []int64
+	var err error
+	if flags&fieldSetFlagBitmap != 0 {
+		ordinals = decodeFieldSetBitmap(data)
+	} else {
+		ordinals, err = decodeFieldSetList(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	fields := make([]F, 0, len(ordinals))
+	for _, ordinal := range ordinals {
+		if err := s.checkOrdinal(ordinal); err != nil {
+			return err
+		}
+		fields = append(fields, s.fm.getField(ordinal))
+	}
+	s.setFields(fields)
+	return nil
+}
+
+func (s *FieldSet[F, T]) checkPathTag() error {
+	tag := s.options.pathTag
+	if len(tag) == 0 {
+		return nil
+	}
+	for _, configured := range s.fm.options.structTags {
+		if configured == tag {
+			return nil
+		}
+	}
+	return fmt.Errorf("fieldmap: struct tag %q is not configured", tag)
+}
+
+// Paths returns the full struct tags (or full field names) of the fields in ordinal order
+func (s *FieldSet[F, T]) Paths() ([]string, error) {
+	if err := s.checkPathTag(); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(s.fields))
+	for _, field := range s.fields {
+		if err := s.checkOrdinal(int64(field)); err != nil {
+			return nil, err
+		}
+		if field == s.fm.structRoot {
+			return nil, errors.New("fieldmap: root field can not be encoded as a path")
+		}
+
+		if len(s.options.pathTag) == 0 {
+			paths = append(paths, s.fm.GetFullFieldName(field))
+		} else {
+			paths = append(paths, s.fm.GetFullStructTag(s.options.pathTag, field))
+		}
+	}
+	return paths, nil
+}
+
+// SetPaths replaces the fields of the set with the fields of the paths
+func (s *FieldSet[F, T]) SetPaths(paths []string) error {
+	if err := s.checkPathTag(); err != nil {
+		return err
+	}
+
+	fields := make([]F, 0, len(paths))
+	for _, path := range paths {
+		var field F
+		var ok bool
+		if len(s.options.pathTag) == 0 {
+			field, ok = s.fm.FindByFullFieldName(path)
+		} else {
+			field, ok = s.fm.FindByFullStructTag(s.options.pathTag, path)
+		}
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrFieldSetUnknownPath, path)
+		}
+		fields = append(fields, field)
+	}
+	s.setFields(fields)
+	return nil
+}
+
+// MarshalJSON encodes the set as an array of paths
+func (s *FieldSet[F, T]) MarshalJSON() ([]byte, error) {
+	paths, err := s.Paths()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(paths)
+}
+
+// UnmarshalJSON decodes an array of paths
+func (s *FieldSet[F, T]) UnmarshalJSON(data []byte) error {
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return err
+	}
+	return s.SetPaths(paths)
+}
+
+// MarshalText encodes the set as a comma-separated list of paths
+func (s *FieldSet[F, T]) MarshalText() ([]byte, error) {
+	paths, err := s.Paths()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.Join(paths, ",")), nil
+}
+
+// UnmarshalText decodes a comma-separated list of paths
+func (s *FieldSet[F, T]) UnmarshalText(data []byte) error {
+	var paths []string
+	if len(data) > 0 {
+		for _, path := range strings.Split(string(data), ",") {
+			paths = append(paths, strings.TrimSpace(path))
+		}
+	}
+	return s.SetPaths(paths)
+}
+
+func decodeFieldSetBitmap(data []byte) []int64 {
+	var ordinals []int64
+	for i, b := range data {
+		for bit := 0; bit < 8; bit++ {
+			if b&(1<<bit) != 0 {
+				ordinals = append(ordinals, int64(i*8+bit)+1)
+			}
+		}
+	}
+	return ordinals
+}
+
+func decodeFieldSetList(data []byte) ([]int64, error) {
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: invalid count", ErrFieldSetInvalidData)
+	}
+	data = data[n:]
+
+	if count > uint64(len(data)) {
+		return nil, fmt.Errorf("%w: invalid count", ErrFieldSetInvalidData)
+	}
+
+	ordinals := make([]int64, 0, count)
+	prev := int64(0)
+	for i := uint64(0); i < count; i++ {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("%w: invalid field delta", ErrFieldSetInvalidData)
+		}
+		data = data[n:]
+
+		prev += int64(delta)
+		ordinals = append(ordinals, prev)
+	}
+
+	if len(data) > 0 {
+		return nil, fmt.Errorf("%w: unexpected trailing bytes", ErrFieldSetInvalidData)
+	}
+	return ordinals, nil
+}
+
+func appendUvarint(data []byte, x uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], x)
+	return append(data, buf[:n]...)
+}
@@ -0,0 +1,224 @@
+package fieldmap
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFieldSet_Binary(t *testing.T) {
+	fm := New[field, productData](WithStructTags("json"))
+	p := fm.GetMapping()
+
+	t.Run("delta list", func(t *testing.T) {
+		s := NewFieldSet(fm, []field{p.ImageURL, p.ImageURL})
+		assert.Equal(t, []field{p.ImageURL}, s.Fields())
+
+		data, err := s.MarshalBinary()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []byte{1, 0, 1, 11}, data)
+
+		decoded := NewFieldSet[field](fm, nil)
+		err = decoded.UnmarshalBinary(data)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []field{p.ImageURL}, decoded.Fields())
+
+		decoded = NewFieldSet[field](fm, nil)
+		err = decoded.UnmarshalBinary([]byte{1, 0, 2, 2, 9})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []field{p.Sku, p.ImageURL}, decoded.Fields())
+	})
+
+	t.Run("bitmap", func(t *testing.T) {
+		s := NewFieldSet(fm, []field{p.Root, p.Sku, p.Name, p.Seller.ID, p.Seller.Attr.Code, p.ImageURL})
+
+		data, err := s.MarshalBinary()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []byte{1, 2, 0b00010111, 0b00000101}, data)
+
+		decoded := NewFieldSet[field](fm, nil)
+		err = decoded.UnmarshalBinary(data)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, s.Fields(), decoded.Fields())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		data, err := NewFieldSet[field](fm, nil).MarshalBinary()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []byte{1, 2}, data)
+
+		decoded := NewFieldSet(fm, []field{p.Sku})
+		err = decoded.UnmarshalBinary(data)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(decoded.Fields()))
+	})
+
+	t.Run("with fingerprint", func(t *testing.T) {
+		s := NewFieldSet(fm, []field{p.ImageURL}, WithFingerprint())
+
+		data, err := s.MarshalBinary()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2+8+2, len(data))
+		assert.Equal(t, byte(1), data[1])
+
+		decoded := NewFieldSet[field](fm, nil, WithFingerprint())
+		err = decoded.UnmarshalBinary(data)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []field{p.ImageURL}, decoded.Fields())
+
+		otherFm := New[field, productDataV2](WithStructTags("json"))
+		err = NewFieldSet[field](otherFm, nil).UnmarshalBinary(data)
+		assert.Equal(t, ErrFieldSetFingerprintMismatch, err)
+	})
+
+	t.Run("fingerprint with registered keys", func(t *testing.T) {
+		newCatalog := func(keys ...string) *FieldMap[field, catalogData] {
+			catalogFm := New[field, catalogData](WithStructTags("json"))
+			for _, key := range keys {
+				catalogFm.RegisterKey(catalogFm.GetMapping().Attributes, key)
+			}
+			return catalogFm
+		}
+
+		catalogFm := newCatalog("color", "size")
+		color, _ := catalogFm.GetKey(catalogFm.GetMapping().Attributes, "color")
+
+		data, err := NewFieldSet(catalogFm, []field{color}, WithFingerprint()).MarshalBinary()
+		assert.Equal(t, nil, err)
+
+		decoded := NewFieldSet[field](newCatalog("color", "size"), nil, WithFingerprint())
+		assert.Equal(t, nil, decoded.UnmarshalBinary(data))
+		assert.Equal(t, []field{color}, decoded.Fields())
+
+		err = NewFieldSet[field](newCatalog("size", "color"), nil).UnmarshalBinary(data)
+		assert.Equal(t, ErrFieldSetFingerprintMismatch, err)
+
+		err = NewFieldSet[field](newCatalog(), nil).UnmarshalBinary(data)
+		assert.Equal(t, ErrFieldSetFingerprintMismatch, err)
+	})
+
+	t.Run("missing fingerprint", func(t *testing.T) {
+		data, err := NewFieldSet(fm, []field{p.Name}).MarshalBinary()
+		assert.Equal(t, nil, err)
+
+		err = NewFieldSet[field](fm, nil, WithFingerprint()).UnmarshalBinary(data)
+		assert.Equal(t, true, errors.Is(err, ErrFieldSetInvalidData))
+		assert.Equal(t, "fieldmap: invalid field set data: missing fingerprint", err.Error())
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		s := NewFieldSet[field](fm, nil)
+
+		assert.Equal(t, "fieldmap: invalid field set data: missing header", s.UnmarshalBinary(nil).Error())
+		assert.Equal(t,
+			"fieldmap: invalid field set data: unsupported version 2",
+			s.UnmarshalBinary([]byte{2, 0, 0}).Error(),
+		)
+		assert.Equal(t,
+			"fieldmap: invalid field set data: invalid field 12",
+			s.UnmarshalBinary([]byte{1, 0, 1, 12}).Error(),
+		)
+		assert.Equal(t,
+			"fieldmap: invalid field set data: invalid field delta",
+			s.UnmarshalBinary([]byte{1, 0, 2, 3, 0x80}).Error(),
+		)
+		assert.Equal(t,
+			"fieldmap: invalid field set data: unexpected trailing bytes",
+			s.UnmarshalBinary([]byte{1, 0, 1, 3, 4}).Error(),
+		)
+		assert.Equal(t,
+			"fieldmap: invalid field set data: invalid field 12",
+			s.UnmarshalBinary([]byte{1, 2, 0, 0b1000}).Error(),
+		)
+	})
+
+	t.Run("marshal invalid field", func(t *testing.T) {
+		_, err := NewFieldSet(fm, []field{12}).MarshalBinary()
+		assert.Equal(t, "fieldmap: invalid field set data: invalid field 12", err.Error())
+	})
+}
+
+type fieldSetJSONMessage struct {
+	ID      int                           `json:"id"`
+	Changed *FieldSet[field, productData] `json:"changed"`
+}
+
+func TestFieldSet_Paths(t *testing.T) {
+	fm := New[field, productData](WithStructTags("json"))
+	p := fm.GetMapping()
+
+	t.Run("json with tag", func(t *testing.T) {
+		s := NewFieldSet(fm, []field{p.Seller.Attr.Code, p.Sku}, WithPathTag("json"))
+
+		data, err := json.Marshal(s)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `["sku","seller.attr.code"]`, string(data))
+
+		decoded := NewFieldSet[field](fm, nil, WithPathTag("json"))
+		err = json.Unmarshal([]byte(`["seller","imageUrl","sku"]`), decoded)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []field{p.Sku, p.Seller.Root, p.ImageURL}, decoded.Fields())
+	})
+
+	t.Run("json field in struct", func(t *testing.T) {
+		msg := fieldSetJSONMessage{
+			ID:      10,
+			Changed: NewFieldSet(fm, []field{p.Name}, WithPathTag("json")),
+		}
+		data, err := json.Marshal(msg)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `{"id":10,"changed":["name"]}`, string(data))
+
+		decoded := fieldSetJSONMessage{
+			Changed: NewFieldSet[field](fm, nil, WithPathTag("json")),
+		}
+		err = json.Unmarshal(data, &decoded)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []field{p.Name}, decoded.Changed.Fields())
+	})
+
+	t.Run("text with full field names", func(t *testing.T) {
+		s := NewFieldSet(fm, []field{p.Seller.Attr.Code, p.Sku})
+
+		data, err := s.MarshalText()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Sku,Seller.Attr.Code", string(data))
+
+		decoded := NewFieldSet[field](fm, nil)
+		err = decoded.UnmarshalText([]byte("Seller.Logo, Name"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []field{p.Name, p.Seller.Logo}, decoded.Fields())
+
+		err = decoded.UnmarshalText(nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(decoded.Fields()))
+	})
+
+	t.Run("map keys", func(t *testing.T) {
+		catalogFm := New[field, catalogData](WithStructTags("json"))
+		color := catalogFm.RegisterKey(catalogFm.GetMapping().Attributes, "color")
+
+		decoded := NewFieldSet[field](catalogFm, nil, WithPathTag("json"))
+		err := decoded.UnmarshalText([]byte("attributes.color"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []field{color}, decoded.Fields())
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		decoded := NewFieldSet[field](fm, nil, WithPathTag("json"))
+		err := json.Unmarshal([]byte(`["sku","seller.email"]`), decoded)
+		assert.Equal(t, true, errors.Is(err, ErrFieldSetUnknownPath))
+		assert.Equal(t, `fieldmap: unknown field path: "seller.email"`, err.Error())
+	})
+
+	t.Run("tag not configured", func(t *testing.T) {
+		_, err := NewFieldSet(fm, []field{p.Sku}, WithPathTag("db")).MarshalText()
+		assert.Equal(t, `fieldmap: struct tag "db" is not configured`, err.Error())
+	})
+
+	t.Run("root field", func(t *testing.T) {
+		_, err := NewFieldSet(fm, []field{p.Root}).MarshalJSON()
+		assert.Equal(t, `fieldmap: root field can not be encoded as a path`, err.Error())
+	})
+}
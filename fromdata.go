@@ -0,0 +1,140 @@
+package fieldmap
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// NewFromData creates a FieldMap directly from a data struct D, without a hand-written mapping struct.
+// Ordinals are assigned in the same DFS order as New, fields are addressed by full field names.
+// Nested structs and pointers to structs are sub-trees, maps with string keys are map nodes,
+// other fields (including time.Time and types implementing json.Marshaler) are leaves.
+// Struct tags are taken from the data struct, without options after the comma,
+// e.g. `json:"sku,omitempty"` becomes "sku".
+func NewFromData[F Field, D any](options ...Option) *DynamicFieldMap[F] {
+	var data D
+	dataType := reflect.TypeOf(data)
+	if dataType == nil || dataType.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("data type %v is not a struct", dataType))
+	}
+
+	b := &dataSchemaBuilder{
+		structTags: computeOptions(options).structTags,
+	}
+	schema := b.buildStruct(dataType, "", nil)
+
+	fm, err := NewDynamic[F](schema, options...)
+	if err != nil {
+		panic(err.Error())
+	}
+	fm.dataTypes = b.dataTypes
+
+	return fm
+}
+
+// GetDataType returns the type of the field in the bound data struct, nil if not bound to a data struct
+func (f *FieldMap[F, T]) GetDataType(field F) reflect.Type {
+	if root, ok := f.mapKeyOf[field]; ok {
+		field = f.mapNodes[root].anyField
+	}
+
+	index := f.indexOf(field)
+	if index >= int64(len(f.dataTypes)) {
+		return nil
+	}
+	return f.dataTypes[index]
+}
+
+type dataSchemaBuilder struct {
+	structTags []string
+
+	// data types in the same order as ordinals
+	dataTypes []reflect.Type
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func isDataLeafStruct(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+	if ptr.Implements(jsonMarshalerType) || ptr.Implements(textMarshalerType) {
+		return true
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *dataSchemaBuilder) buildStruct(
+	t reflect.Type, fullFieldName string, structTypes []reflect.Type,
+) SchemaNode {
+	for _, structType := range structTypes {
+		if structType == t {
+			panic(fmt.Sprintf("recursive struct type for field %q", fullFieldName))
+		}
+	}
+	structTypes = append(structTypes, t)
+
+	b.dataTypes = append(b.dataTypes, t)
+
+	var children []SchemaNode
+	for _, info := range collectStructFields(reflect.New(t).Elem(), fullFieldName) {
+		children = append(children, b.buildField(info, fullFieldName, structTypes))
+	}
+	return SchemaNode{Children: children}
+}
+
+func (b *dataSchemaBuilder) buildField(
+	info structFieldInfo, parentFullName string, structTypes []reflect.Type,
+) SchemaNode {
+	fieldName := info.field.Name
+	fullFieldName := joinFieldName(parentFullName, fieldName)
+
+	fieldType := info.field.Type
+	if isPointerToStruct(fieldType) {
+		fieldType = fieldType.Elem()
+	}
+
+	var node SchemaNode
+
+	switch {
+	case info.options.leaf:
+		b.dataTypes = append(b.dataTypes, info.field.Type)
+
+	case fieldType.Kind() == reflect.Struct && !isDataLeafStruct(fieldType):
+		node = b.buildStruct(fieldType, fullFieldName, structTypes)
+
+	case fieldType.Kind() == reflect.Map && fieldType.Key().Kind() == reflect.String:
+		node.Map = true
+		b.dataTypes = append(b.dataTypes, fieldType, fieldType.Elem())
+
+	default:
+		b.dataTypes = append(b.dataTypes, info.field.Type)
+	}
+
+	node.Name = fieldName
+	node.Attributes = info.options.attributes.Names()
+	node.ID = info.options.stableID
+	node.Tags = map[string]string{}
+	for _, tag := range b.structTags {
+		tagVal, ok := info.field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		tagName := strings.Split(tagVal, ",")[0]
+		if len(tagName) == 0 {
+			tagName = fieldName
+		}
+		node.Tags[tag] = tagName
+	}
+	return node
+}
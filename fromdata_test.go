@@ -0,0 +1,103 @@
+package fieldmap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Seller struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name,omitempty"`
+	Logo string `json:"logo"`
+	Attr struct {
+		Code string `json:"code"`
+		Name string `json:"name"`
+	} `json:"attr"`
+}
+
+type Product struct {
+	Sku        string            `json:"sku"`
+	Name       string            `json:"name" fieldmap:"required"`
+	Seller     *Seller           `json:"seller"`
+	Attributes map[string]string `json:"attributes"`
+	CreatedAt  time.Time         `json:"createdAt" fieldmap:"readonly"`
+	Price      money             `json:"price" fieldmap:"leaf"`
+	Internal   string            `json:"-" fieldmap:"-"`
+
+	version int
+}
+
+type productWithoutTag struct {
+	Sku  string `json:"sku"`
+	Name string
+}
+
+func TestNewFromData(t *testing.T) {
+	t.Run("same ordinals as mapping struct", func(t *testing.T) {
+		dataFm := NewFromData[field, Product](WithStructTags("json"))
+		fm := New[field, productData](WithStructTags("json"))
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, p.Sku, dataFm.Field("Sku"))
+		assert.Equal(t, p.Name, dataFm.Field("Name"))
+		assert.Equal(t, p.Seller.Root, dataFm.Field("Seller"))
+		assert.Equal(t, p.Seller.Logo, dataFm.Field("Seller.Logo"))
+		assert.Equal(t, p.Seller.Attr.Root, dataFm.Field("Seller.Attr"))
+		assert.Equal(t, p.Seller.Attr.Name, dataFm.Field("Seller.Attr.Name"))
+
+		assert.Equal(t, fm.ChildrenOf(p.Seller.Root), dataFm.ChildrenOf(dataFm.Field("Seller")))
+		assert.Equal(t, "seller.attr.code", dataFm.GetFullStructTag("json", dataFm.Field("Seller.Attr.Code")))
+		assert.Equal(t, "name", dataFm.GetStructTag("json", dataFm.Field("Seller.Name")))
+	})
+
+	t.Run("other fields", func(t *testing.T) {
+		fm := NewFromData[field, Product](WithStructTags("json"))
+
+		attrs := fm.MapNode("Attributes")
+		assert.Equal(t, field(11), attrs.Root)
+		assert.Equal(t, field(12), attrs.Any)
+		assert.Equal(t, field(13), fm.Field("CreatedAt"))
+		assert.Equal(t, field(14), fm.Field("Price"))
+
+		_, ok := fm.FindByFullFieldName("Internal")
+		assert.Equal(t, false, ok)
+
+		assert.Equal(t, false, fm.IsStruct(fm.Field("CreatedAt")))
+		assert.Equal(t, false, fm.IsStruct(fm.Field("Price")))
+
+		assert.Equal(t, true, fm.HasAttribute(fm.Field("Name"), AttributeRequired))
+		assert.Equal(t, true, fm.HasAttribute(fm.Field("CreatedAt"), AttributeReadonly))
+	})
+
+	t.Run("data types", func(t *testing.T) {
+		fm := NewFromData[field, Product]()
+
+		assert.Equal(t, reflect.TypeOf(Product{}), fm.GetDataType(fm.GetMapping().GetRoot()))
+		assert.Equal(t, reflect.TypeOf(""), fm.GetDataType(fm.Field("Sku")))
+		assert.Equal(t, reflect.TypeOf(Seller{}), fm.GetDataType(fm.Field("Seller")))
+		assert.Equal(t, reflect.TypeOf(int64(0)), fm.GetDataType(fm.Field("Seller.ID")))
+		assert.Equal(t, reflect.TypeOf(time.Time{}), fm.GetDataType(fm.Field("CreatedAt")))
+		assert.Equal(t, reflect.TypeOf(map[string]string{}), fm.GetDataType(fm.Field("Attributes")))
+
+		color := fm.RegisterKey(fm.MapNode("Attributes"), "color")
+		assert.Equal(t, reflect.TypeOf(""), fm.GetDataType(color))
+
+		mappingFm := New[field, productData]()
+		assert.Equal(t, nil, mappingFm.GetDataType(mappingFm.GetMapping().Sku))
+	})
+
+	t.Run("panics when missing struct tag", func(t *testing.T) {
+		assert.PanicsWithValue(t, `missing struct tag "json" for field "Name"`, func() {
+			NewFromData[field, productWithoutTag](WithStructTags("json"))
+		})
+	})
+
+	t.Run("panics when not struct", func(t *testing.T) {
+		assert.PanicsWithValue(t, `data type string is not a struct`, func() {
+			NewFromData[field, string]()
+		})
+	})
+}
@@ -0,0 +1,36 @@
+// Package example contains data structs for testing the code generated by fieldmap-gen.
+package example
+
+import "time"
+
+//go:generate go run ../../cmd/fieldmap-gen -type Field -tags json -tables
+
+// Field ...
+type Field int
+
+// Product ...
+//
+//fieldmap:generate
+type Product struct {
+	Sku       string            `json:"sku" fieldmap:"id=1,required"`
+	Name      string            `json:"name,omitempty" fieldmap:"id=2"`
+	Seller    *Seller           `json:"seller" fieldmap:"id=3,readonly"`
+	Attrs     map[string]string `json:"attrs" fieldmap:"id=4"`
+	CreatedAt time.Time         `json:"createdAt" fieldmap:"id=5,immutable"`
+	Category  Category          `json:"category" fieldmap:"id=6"`
+}
+
+// Seller ...
+type Seller struct {
+	ID   int64  `json:"id" fieldmap:"id=1"`
+	Name string `json:"name" fieldmap:"id=2,deprecated"`
+	Info struct {
+		Logo string `json:"logo" fieldmap:"id=1"`
+	} `json:"info" fieldmap:"id=3"`
+}
+
+// Category ...
+type Category struct {
+	Root string `json:"root" fieldmap:"id=1"`
+	Name string `json:"name" fieldmap:"id=2"`
+}
@@ -0,0 +1,46 @@
+package example
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/QuangTung97/fieldmap"
+	"github.com/QuangTung97/fieldmap/internal/gen"
+)
+
+func TestGeneratedCode(t *testing.T) {
+	content, err := gen.GenerateDir(".", gen.Config{
+		FieldType: "Field",
+		Tags:      []string{"json"},
+		Tables:    true,
+	})
+	assert.Equal(t, nil, err)
+
+	generated, err := os.ReadFile("fieldmap_gen.go")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, string(content), string(generated), "run go generate to update fieldmap_gen.go")
+}
+
+func TestProductMappingTables(t *testing.T) {
+	assert.Equal(t, nil, fieldmap.VerifyTables(ProductMappingTables))
+
+	fm := fieldmap.NewFromTables(ProductMappingTables)
+	expected := fieldmap.New[Field, ProductMapping](fieldmap.WithStructTags("json"))
+
+	assert.Equal(t, expected.GetMapping(), fm.GetMapping())
+	assert.Equal(t, expected.Fingerprint(), fm.Fingerprint())
+
+	m := fm.GetMapping()
+	field, ok := fm.FindByFullStructTag("json", "seller.info.logo")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, m.Seller.Info.Logo, field)
+	assert.Equal(t, []Field{m.Seller.Info.Root, m.Seller.Root, m.Root}, fm.AncestorOf(m.Seller.Info.Root))
+
+	assert.Equal(t, "Category.Root", fm.GetFullFieldName(m.Category.Root))
+	assert.Equal(t, m.Category.MappingRoot, fm.ParentOf(m.Category.Root))
+
+	color := fm.RegisterKey(m.Attrs, "color")
+	assert.Equal(t, "Attrs.color", fm.GetFullFieldName(color))
+}
@@ -0,0 +1,115 @@
+// Code generated by fieldmap-gen. DO NOT EDIT.
+
+package example
+
+import "github.com/QuangTung97/fieldmap"
+
+//fieldmap:data Product
+type ProductMapping struct {
+	Root Field
+
+	Sku       Field                   `json:"sku" fieldmap:"id=1,required"`
+	Name      Field                   `json:"name" fieldmap:"id=2"`
+	Seller    SellerMapping           `json:"seller" fieldmap:"id=3,readonly"`
+	Attrs     fieldmap.MapNode[Field] `json:"attrs" fieldmap:"id=4"`
+	CreatedAt Field                   `json:"createdAt" fieldmap:"id=5,immutable"`
+	Category  CategoryMapping         `json:"category" fieldmap:"id=6"`
+}
+
+// GetRoot ...
+func (m ProductMapping) GetRoot() Field {
+	return m.Root
+}
+
+//fieldmap:data Seller
+type SellerMapping struct {
+	Root Field
+
+	ID   Field             `json:"id" fieldmap:"id=1"`
+	Name Field             `json:"name" fieldmap:"id=2,deprecated"`
+	Info SellerInfoMapping `json:"info" fieldmap:"id=3"`
+}
+
+// GetRoot ...
+func (m SellerMapping) GetRoot() Field {
+	return m.Root
+}
+
+//fieldmap:data Seller.Info
+type SellerInfoMapping struct {
+	Root Field
+
+	Logo Field `json:"logo" fieldmap:"id=1"`
+}
+
+// GetRoot ...
+func (m SellerInfoMapping) GetRoot() Field {
+	return m.Root
+}
+
+//fieldmap:data Category
+type CategoryMapping struct {
+	MappingRoot Field `fieldmap:"root"`
+
+	Root Field `json:"root" fieldmap:"id=1"`
+	Name Field `json:"name" fieldmap:"id=2"`
+}
+
+// GetRoot ...
+func (m CategoryMapping) GetRoot() Field {
+	return m.MappingRoot
+}
+
+// ProductMappingTables contains the FieldMap of ProductMapping, for fieldmap.NewFromTables
+var ProductMappingTables = fieldmap.Tables[Field, ProductMapping]{
+	Mapping: ProductMapping{
+		Root: 1,
+		Sku:  2,
+		Name: 3,
+		Seller: SellerMapping{
+			Root: 4,
+			ID:   5,
+			Name: 6,
+			Info: SellerInfoMapping{
+				Root: 7,
+				Logo: 8,
+			},
+		},
+		Attrs:     fieldmap.MapNode[Field]{Root: 9, Any: 10},
+		CreatedAt: 11,
+		Category: CategoryMapping{
+			MappingRoot: 12,
+			Root:        13,
+			Name:        14,
+		},
+	},
+
+	StructTags: []string{"json"},
+
+	Names:   []string{"", "Sku", "Name", "Seller", "ID", "Name", "Info", "Logo", "Attrs", "*", "CreatedAt", "Category", "Root", "Name"},
+	Parents: []Field{0, 1, 1, 1, 4, 4, 4, 7, 1, 9, 1, 1, 12, 12},
+	Tags: map[string][]string{
+		"json": {"", "sku", "name", "seller", "id", "name", "info", "logo", "attrs", "*", "createdAt", "category", "root", "name"},
+	},
+
+	Attributes: []fieldmap.Attribute{
+		0,
+		fieldmap.AttributeRequired,
+		0,
+		fieldmap.AttributeReadonly,
+		fieldmap.AttributeReadonly,
+		fieldmap.AttributeReadonly | fieldmap.AttributeDeprecated,
+		fieldmap.AttributeReadonly,
+		fieldmap.AttributeReadonly,
+		0,
+		0,
+		fieldmap.AttributeImmutable,
+		0,
+		0,
+		0,
+	},
+
+	StableIDs: []uint32{0, 1, 2, 3, 1, 2, 3, 1, 4, 0, 5, 6, 1, 2},
+
+	MapNodes: []Field{9},
+}
@@ -0,0 +1,507 @@
+// Package gen generates mapping structs from annotated data structs, used by cmd/fieldmap-gen.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GenerateComment is the annotation of data structs, optionally followed by the name of the mapping struct.
+// The default name of the mapping struct is the name of the data struct with the suffix MappingSuffix.
+const GenerateComment = "//fieldmap:generate"
+
+// DataComment is emitted before each generated mapping struct, followed by the name of its data struct
+const DataComment = "//fieldmap:data"
+
+// MappingSuffix ...
+const MappingSuffix = "Mapping"
+
+// HeaderComment is the first line of generated files, files starting with it are not parsed
+const HeaderComment = "// Code generated by fieldmap-gen. DO NOT EDIT."
+
+const fieldmapImportPath = "github.com/QuangTung97/fieldmap"
+
+const (
+	rootFieldName = "Root"
+	fieldmapTag   = "fieldmap"
+
+	// mappingRootFieldName is the name of the root field for data structs having a field named Root
+	mappingRootFieldName = "MappingRoot"
+)
+
+// Config ...
+type Config struct {
+	// FieldType is the name of the field type F, required
+	FieldType string
+
+	// Tags are the struct tags copied from data structs, without options after the comma.
+	// Fields without the tag use the field name.
+	Tags []string
+
+	// Tables also generates fieldmap.Tables of annotated data structs, for fieldmap.NewFromTables
+	Tables bool
+}
+
+// GenerateDir parses the non-test Go files of a directory and generates the mapping structs
+func GenerateDir(dir string, conf Config) ([]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(content, []byte(HeaderComment)) {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), content, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no go files in %q", dir)
+	}
+	return Generate(files, conf)
+}
+
+// Generate generates the mapping structs of the annotated data structs in the files of a package,
+// the result is formatted Go source code
+func Generate(files []*ast.File, conf Config) ([]byte, error) {
+	if len(conf.FieldType) == 0 {
+		return nil, fmt.Errorf("missing field type")
+	}
+
+	g := &generator{
+		conf:    conf,
+		structs: map[string]*ast.StructType{},
+		names:   map[string]string{},
+		nodes:   map[string]*structNode{},
+	}
+
+	var annotated []string
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				g.structs[typeSpec.Name.Name] = structType
+
+				doc := typeSpec.Doc
+				if doc == nil && len(genDecl.Specs) == 1 {
+					doc = genDecl.Doc
+				}
+				if mappingName, ok := findGenerateComment(doc); ok {
+					if len(mappingName) == 0 {
+						mappingName = typeSpec.Name.Name + MappingSuffix
+					}
+					g.names[typeSpec.Name.Name] = mappingName
+					annotated = append(annotated, typeSpec.Name.Name)
+				}
+			}
+		}
+	}
+
+	var roots []*structNode
+	for _, name := range annotated {
+		node, err := g.generateNamed(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, node)
+	}
+
+	for _, node := range g.order {
+		g.writeStruct(node)
+	}
+	if conf.Tables {
+		for _, node := range roots {
+			if err := g.writeTables(node); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(HeaderComment + "\n\n")
+	buf.WriteString("package " + files[0].Name.Name + "\n\n")
+	if g.hasMapNode || conf.Tables {
+		buf.WriteString("import \"" + fieldmapImportPath + "\"\n\n")
+	}
+	buf.Write(g.body.Bytes())
+
+	return format.Source(buf.Bytes())
+}
+
+func findGenerateComment(doc *ast.CommentGroup) (string, bool) {
+	if doc == nil {
+		return "", false
+	}
+	for _, comment := range doc.List {
+		if comment.Text == GenerateComment {
+			return "", true
+		}
+		if strings.HasPrefix(comment.Text, GenerateComment+" ") {
+			return strings.TrimSpace(strings.TrimPrefix(comment.Text, GenerateComment)), true
+		}
+	}
+	return "", false
+}
+
+type generator struct {
+	conf Config
+
+	// structs declared in the package
+	structs map[string]*ast.StructType
+	// names of mapping structs for data structs
+	names map[string]string
+
+	// nodes of named data structs
+	nodes map[string]*structNode
+	// order of generated mapping structs
+	order []*structNode
+
+	hasMapNode bool
+	body       bytes.Buffer
+}
+
+// structNode is a generated mapping struct
+type structNode struct {
+	dataName    string
+	mappingName string
+	fields      []*fieldNode
+
+	// rootName is the name of the root field, tagged with fieldmap:"root" if it is not rootFieldName
+	rootName string
+}
+
+type fieldNode struct {
+	name     string
+	typeName string
+
+	// tagValues are the values of the copied struct tags, in the order of Config.Tags
+	tagValues []string
+	// options is the fieldmap tag of the data field
+	options    string
+	hasOptions bool
+
+	mapNode bool
+	nested  *structNode
+}
+
+func (g *generator) mappingNameOf(dataName string) string {
+	name, ok := g.names[dataName]
+	if !ok {
+		return dataName + MappingSuffix
+	}
+	return name
+}
+
+func (g *generator) generateNamed(dataName string, dataTypes []string) (*structNode, error) {
+	for _, t := range dataTypes {
+		if t == dataName {
+			return nil, fmt.Errorf("recursive struct type %q", dataName)
+		}
+	}
+	if node, ok := g.nodes[dataName]; ok {
+		return node, nil
+	}
+
+	node, err := g.generateStruct(dataName, g.mappingNameOf(dataName), g.structs[dataName], dataTypes)
+	if err != nil {
+		return nil, err
+	}
+	g.nodes[dataName] = node
+	return node, nil
+}
+
+func (g *generator) generateStruct(
+	dataName string, mappingName string, structType *ast.StructType, dataTypes []string,
+) (*structNode, error) {
+	dataTypes = append(dataTypes, dataName)
+
+	fields, err := g.collectFields(dataName, structType, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, other := range g.order {
+		if other.mappingName == mappingName {
+			return nil, fmt.Errorf(
+				"mapping struct name %q of %q conflicts with %q", mappingName, dataName, other.dataName,
+			)
+		}
+	}
+
+	node := &structNode{
+		dataName:    dataName,
+		mappingName: mappingName,
+		rootName:    rootNameOf(fields),
+	}
+	g.order = append(g.order, node)
+
+	for _, f := range fields {
+		field, err := g.buildField(dataName, f, dataTypes)
+		if err != nil {
+			return nil, err
+		}
+		node.fields = append(node.fields, field)
+	}
+	return node, nil
+}
+
+// rootNameOf returns rootFieldName, or another name if a data field is named rootFieldName.
+// The root field with another name is tagged with fieldmap:"root".
+func rootNameOf(fields []dataField) string {
+	names := map[string]bool{}
+	for _, f := range fields {
+		names[f.name] = true
+	}
+	if !names[rootFieldName] {
+		return rootFieldName
+	}
+
+	name := mappingRootFieldName
+	for i := 2; names[name]; i++ {
+		name = mappingRootFieldName + strconv.Itoa(i)
+	}
+	return name
+}
+
+// buildField computes the type of the field in the mapping struct,
+// and generates the nested mapping struct if needed
+func (g *generator) buildField(dataName string, f dataField, dataTypes []string) (*fieldNode, error) {
+	tag := structTagOf(f.field)
+
+	field := &fieldNode{
+		name:     f.name,
+		typeName: g.conf.FieldType,
+	}
+	for _, name := range g.conf.Tags {
+		value, ok := tag.Lookup(name)
+		if !ok {
+			value = f.name
+		}
+		field.tagValues = append(field.tagValues, strings.Split(value, ",")[0])
+	}
+	field.options, field.hasOptions = tag.Lookup(fieldmapTag)
+
+	if hasTagOption(f.field, "leaf") {
+		return field, nil
+	}
+
+	fieldType := f.field.Type
+	if star, ok := fieldType.(*ast.StarExpr); ok {
+		fieldType = star.X
+	}
+
+	var err error
+	switch t := fieldType.(type) {
+	case *ast.Ident:
+		if _, ok := g.structs[t.Name]; ok {
+			field.typeName = g.mappingNameOf(t.Name)
+			field.nested, err = g.generateNamed(t.Name, dataTypes)
+		}
+
+	case *ast.StructType:
+		// the path of the inline struct without dots, e.g. ProductSellerInfoMapping for Product.Seller.Info
+		field.typeName = strings.ReplaceAll(dataName, ".", "") + f.name + MappingSuffix
+		field.nested, err = g.generateStruct(dataName+"."+f.name, field.typeName, t, dataTypes)
+
+	case *ast.MapType:
+		if key, ok := t.Key.(*ast.Ident); ok && key.Name == "string" {
+			g.hasMapNode = true
+			field.typeName = "fieldmap.MapNode[" + g.conf.FieldType + "]"
+			field.mapNode = true
+		}
+	}
+	return field, err
+}
+
+func (f *fieldNode) tag(tags []string) string {
+	var parts []string
+	for i, name := range tags {
+		parts = append(parts, name+":"+strconv.Quote(f.tagValues[i]))
+	}
+	if f.hasOptions {
+		parts = append(parts, fieldmapTag+":"+strconv.Quote(f.options))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (g *generator) writeStruct(node *structNode) {
+	fieldType := g.conf.FieldType
+
+	fmt.Fprintf(&g.body, "%s %s\n", DataComment, node.dataName)
+	fmt.Fprintf(&g.body, "type %s struct {\n", node.mappingName)
+	if node.rootName == rootFieldName {
+		fmt.Fprintf(&g.body, "%s %s\n\n", node.rootName, fieldType)
+	} else {
+		fmt.Fprintf(&g.body, "%s %s `%s:\"root\"`\n\n", node.rootName, fieldType, fieldmapTag)
+	}
+	for _, f := range node.fields {
+		tag := f.tag(g.conf.Tags)
+		if len(tag) == 0 {
+			fmt.Fprintf(&g.body, "%s %s\n", f.name, f.typeName)
+			continue
+		}
+		fmt.Fprintf(&g.body, "%s %s `%s`\n", f.name, f.typeName, tag)
+	}
+	fmt.Fprintf(&g.body, "}\n\n")
+
+	fmt.Fprintf(&g.body, "// GetRoot ...\n")
+	fmt.Fprintf(&g.body, "func (m %s) GetRoot() %s {\n", node.mappingName, fieldType)
+	fmt.Fprintf(&g.body, "return m.%s\n", node.rootName)
+	fmt.Fprintf(&g.body, "}\n\n")
+}
+
+type dataField struct {
+	name  string
+	depth int
+	field *ast.Field
+}
+
+// collectFields returns the exported fields of a data struct, fields of embedded structs declared in
+// the same package are promoted, with the same dominance rules as the fieldmap package
+func (g *generator) collectFields(dataName string, structType *ast.StructType, embedded []string) ([]dataField, error) {
+	for _, name := range embedded {
+		if name == dataName {
+			return nil, fmt.Errorf("recursive embedded struct %q", dataName)
+		}
+	}
+	embedded = append(embedded, dataName)
+
+	var result []dataField
+	for _, field := range structType.Fields.List {
+		tag := structTagOf(field)
+		if tag.Get(fieldmapTag) == "-" {
+			continue
+		}
+
+		if len(field.Names) == 0 {
+			embeddedFields, err := g.collectEmbedded(field, embedded)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, embeddedFields...)
+			continue
+		}
+
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			result = append(result, dataField{name: name.Name, field: field})
+		}
+	}
+
+	return dominantFields(dataName, result)
+}
+
+func (g *generator) collectEmbedded(field *ast.Field, embedded []string) ([]dataField, error) {
+	fieldType := field.Type
+	if star, ok := fieldType.(*ast.StarExpr); ok {
+		fieldType = star.X
+	}
+
+	ident, ok := fieldType.(*ast.Ident)
+	if !ok {
+		sel, ok := fieldType.(*ast.SelectorExpr)
+		if !ok {
+			return nil, nil
+		}
+		// embedded types of other packages are leaves
+		return []dataField{{name: sel.Sel.Name, field: field}}, nil
+	}
+
+	structType, ok := g.structs[ident.Name]
+	if !ok {
+		if !ast.IsExported(ident.Name) {
+			return nil, nil
+		}
+		return []dataField{{name: ident.Name, field: field}}, nil
+	}
+
+	fields, err := g.collectFields(ident.Name, structType, embedded)
+	if err != nil {
+		return nil, err
+	}
+	for i := range fields {
+		fields[i].depth++
+	}
+	return fields, nil
+}
+
+// dominantFields keeps the fields of the smallest depth for each name, in declaration order
+func dominantFields(dataName string, fields []dataField) ([]dataField, error) {
+	byName := map[string][]dataField{}
+	for _, f := range fields {
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	var result []dataField
+	for _, f := range fields {
+		candidates := byName[f.name]
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].depth < candidates[j].depth
+		})
+		if candidates[0].field != f.field {
+			continue
+		}
+		if len(candidates) > 1 && candidates[1].depth == f.depth {
+			return nil, fmt.Errorf("ambiguous field %q of %q", f.name, dataName)
+		}
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+func structTagOf(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag)
+}
+
+func hasTagOption(field *ast.Field, option string) bool {
+	for _, o := range strings.Split(structTagOf(field).Get(fieldmapTag), ",") {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,383 @@
+package gen
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseSource(t *testing.T, src string) []*ast.File {
+	file, err := parser.ParseFile(token.NewFileSet(), "data.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []*ast.File{file}
+}
+
+func generateSource(t *testing.T, src string, conf Config) (string, error) {
+	content, err := Generate(parseSource(t, src), conf)
+	return string(content), err
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("nested structs", func(t *testing.T) {
+		content, err := generateSource(t, `package product
+
+import "time"
+
+type field int
+
+//fieldmap:generate
+type Product struct {
+	Sku       string            `+"`json:\"sku,omitempty\" db:\"sku\"`"+`
+	Name      string            `+"`fieldmap:\"required\"`"+`
+	Seller    *Seller           `+"`json:\"seller\"`"+`
+	Attrs     map[string]string `+"`json:\"attrs\"`"+`
+	CreatedAt time.Time         `+"`json:\"createdAt\"`"+`
+	Money     Money             `+"`json:\"money\" fieldmap:\"leaf\"`"+`
+	Ignored   string            `+"`fieldmap:\"-\"`"+`
+	internal  string
+}
+
+type Seller struct {
+	ID   int64 `+"`json:\"id\"`"+`
+	Info struct {
+		Logo string `+"`json:\"logo\"`"+`
+	} `+"`json:\"info\"`"+`
+}
+
+type Money struct {
+	Amount int64
+}
+`, Config{FieldType: "field", Tags: []string{"json"}})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, HeaderComment+`
+
+package product
+
+import "github.com/QuangTung97/fieldmap"
+
+//fieldmap:data Product
+type ProductMapping struct {
+	Root field
+
+	Sku       field                   `+"`json:\"sku\"`"+`
+	Name      field                   `+"`json:\"Name\" fieldmap:\"required\"`"+`
+	Seller    SellerMapping           `+"`json:\"seller\"`"+`
+	Attrs     fieldmap.MapNode[field] `+"`json:\"attrs\"`"+`
+	CreatedAt field                   `+"`json:\"createdAt\"`"+`
+	Money     field                   `+"`json:\"money\" fieldmap:\"leaf\"`"+`
+}
+
+// GetRoot ...
+func (m ProductMapping) GetRoot() field {
+	return m.Root
+}
+
+//fieldmap:data Seller
+type SellerMapping struct {
+	Root field
+
+	ID   field             `+"`json:\"id\"`"+`
+	Info SellerInfoMapping `+"`json:\"info\"`"+`
+}
+
+// GetRoot ...
+func (m SellerMapping) GetRoot() field {
+	return m.Root
+}
+
+//fieldmap:data Seller.Info
+type SellerInfoMapping struct {
+	Root field
+
+	Logo field `+"`json:\"logo\"`"+`
+}
+
+// GetRoot ...
+func (m SellerInfoMapping) GetRoot() field {
+	return m.Root
+}
+`, content)
+	})
+
+	t.Run("nested inline structs", func(t *testing.T) {
+		content, err := generateSource(t, `package product
+
+//fieldmap:generate
+type Product struct {
+	Seller struct {
+		Info struct {
+			Logo string
+		}
+	}
+}
+`, Config{FieldType: "field"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, HeaderComment+`
+
+package product
+
+//fieldmap:data Product
+type ProductMapping struct {
+	Root field
+
+	Seller ProductSellerMapping
+}
+
+// GetRoot ...
+func (m ProductMapping) GetRoot() field {
+	return m.Root
+}
+
+//fieldmap:data Product.Seller
+type ProductSellerMapping struct {
+	Root field
+
+	Info ProductSellerInfoMapping
+}
+
+// GetRoot ...
+func (m ProductSellerMapping) GetRoot() field {
+	return m.Root
+}
+
+//fieldmap:data Product.Seller.Info
+type ProductSellerInfoMapping struct {
+	Root field
+
+	Logo field
+}
+
+// GetRoot ...
+func (m ProductSellerInfoMapping) GetRoot() field {
+	return m.Root
+}
+`, content)
+	})
+
+	t.Run("custom names and embedded structs", func(t *testing.T) {
+		content, err := generateSource(t, `package product
+
+//fieldmap:generate ProductFields
+type Product struct {
+	Base
+	Sku    string
+	Seller Seller
+}
+
+type Base struct {
+	ID  int64
+	Sku string
+}
+
+//fieldmap:generate SellerFields
+type Seller struct {
+	Name string
+}
+`, Config{FieldType: "field"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, HeaderComment+`
+
+package product
+
+//fieldmap:data Product
+type ProductFields struct {
+	Root field
+
+	ID     field
+	Sku    field
+	Seller SellerFields
+}
+
+// GetRoot ...
+func (m ProductFields) GetRoot() field {
+	return m.Root
+}
+
+//fieldmap:data Seller
+type SellerFields struct {
+	Root field
+
+	Name field
+}
+
+// GetRoot ...
+func (m SellerFields) GetRoot() field {
+	return m.Root
+}
+`, content)
+	})
+
+	t.Run("data field named Root", func(t *testing.T) {
+		content, err := generateSource(t, `package product
+
+//fieldmap:generate
+type Product struct {
+	Root        string
+	MappingRoot string
+}
+`, Config{FieldType: "field", Tables: true})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, HeaderComment+`
+
+package product
+
+import "github.com/QuangTung97/fieldmap"
+
+//fieldmap:data Product
+type ProductMapping struct {
+	MappingRoot2 field `+"`fieldmap:\"root\"`"+`
+
+	Root        field
+	MappingRoot field
+}
+
+// GetRoot ...
+func (m ProductMapping) GetRoot() field {
+	return m.MappingRoot2
+}
+
+// ProductMappingTables contains the FieldMap of ProductMapping, for fieldmap.NewFromTables
+var ProductMappingTables = fieldmap.Tables[field, ProductMapping]{
+	Mapping: ProductMapping{
+		MappingRoot2: 1,
+		Root:         2,
+		MappingRoot:  3,
+	},
+
+	Names:   []string{"", "Root", "MappingRoot"},
+	Parents: []field{0, 1, 1},
+}
+`, content)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := generateSource(t, "package product\n", Config{})
+		assert.Equal(t, "missing field type", err.Error())
+
+		_, err = generateSource(t, `package product
+
+//fieldmap:generate
+type Node struct {
+	Child *Node
+}
+`, Config{FieldType: "field"})
+		assert.Equal(t, `recursive struct type "Node"`, err.Error())
+
+		_, err = generateSource(t, `package product
+
+//fieldmap:generate
+type Product struct {
+	A
+	B
+}
+
+type A struct {
+	Name string
+}
+
+type B struct {
+	Name string
+}
+`, Config{FieldType: "field"})
+		assert.Equal(t, `ambiguous field "Name" of "Product"`, err.Error())
+
+		_, err = generateSource(t, `package product
+
+//fieldmap:generate
+type Product struct {
+	Base
+}
+
+type Base struct {
+	*Base
+}
+`, Config{FieldType: "field"})
+		assert.Equal(t, `recursive embedded struct "Base"`, err.Error())
+
+		_, err = generateSource(t, `package product
+
+//fieldmap:generate
+type Product struct {
+	Seller struct {
+		Info struct {
+			Logo string
+		}
+	}
+	SellerInfo struct {
+		Logo string
+	}
+}
+`, Config{FieldType: "field"})
+		assert.Equal(t,
+			`mapping struct name "ProductSellerInfoMapping" of "Product.SellerInfo" conflicts with "Product.Seller.Info"`,
+			err.Error())
+	})
+}
+
+func TestGenerate_Tables(t *testing.T) {
+	_, err := generateSource(t, `package product
+
+//fieldmap:generate
+type Product struct {
+	Seller Seller
+}
+
+type Seller struct {
+	ID int64 `+"`fieldmap:\"root\"`"+`
+}
+`, Config{FieldType: "field", Tables: true})
+	assert.Equal(t, `unsupported option "root" for field "Seller.ID"`, err.Error())
+
+	_, err = generateSource(t, `package product
+
+//fieldmap:generate
+type Product struct {
+	Sku string `+"`fieldmap:\"id=0\"`"+`
+}
+`, Config{FieldType: "field", Tables: true})
+	assert.Equal(t, `invalid stable id "id=0" for field "Sku"`, err.Error())
+}
+
+func TestGenerateDir(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name string, content string) {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := GenerateDir(dir, Config{FieldType: "field"})
+	assert.Equal(t, `no go files in "`+dir+`"`, err.Error())
+
+	write("data.go", "package product\n\n//fieldmap:generate\ntype Product struct {\n\tSku string\n}\n")
+	write("data_test.go", "package product\n\n//fieldmap:generate\ntype TestProduct struct {\n\tSku string\n}\n")
+	write("fieldmap_gen.go", HeaderComment+"\n\npackage product\n\n//fieldmap:generate\ntype Old struct{}\n")
+
+	content, err := GenerateDir(dir, Config{FieldType: "field"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, HeaderComment+`
+
+package product
+
+//fieldmap:data Product
+type ProductMapping struct {
+	Root field
+
+	Sku field
+}
+
+// GetRoot ...
+func (m ProductMapping) GetRoot() field {
+	return m.Root
+}
+`, string(content))
+}
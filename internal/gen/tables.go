@@ -0,0 +1,221 @@
+package gen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/QuangTung97/fieldmap"
+)
+
+// TablesSuffix is appended to the name of mapping structs for the names of generated tables
+const TablesSuffix = "Tables"
+
+// attributesByName contains all attributes of the fieldmap package
+var attributesByName = func() map[string]fieldmap.Attribute {
+	result := map[string]fieldmap.Attribute{}
+	for attr := fieldmap.Attribute(1); len(attr.Names()) > 0; attr <<= 1 {
+		result[attr.String()] = attr
+	}
+	return result
+}()
+
+type fieldOptions struct {
+	attributes fieldmap.Attribute
+	stableID   uint32
+}
+
+func parseFieldOptions(fullFieldName string, tag string) (fieldOptions, error) {
+	var result fieldOptions
+	for _, option := range strings.Split(tag, ",") {
+		option = strings.TrimSpace(option)
+		if len(option) == 0 || option == "leaf" {
+			continue
+		}
+
+		if strings.HasPrefix(option, "id=") {
+			id, err := strconv.ParseUint(strings.TrimPrefix(option, "id="), 10, 32)
+			if err != nil || id == 0 {
+				return result, fmt.Errorf("invalid stable id %q for field %q", option, fullFieldName)
+			}
+			result.stableID = uint32(id)
+			continue
+		}
+
+		attr, ok := attributesByName[option]
+		if !ok {
+			return result, fmt.Errorf("unsupported option %q for field %q", option, fullFieldName)
+		}
+		result.attributes |= attr
+	}
+	return result, nil
+}
+
+// tablesBuilder assigns ordinals in the same order as fieldmap.New
+type tablesBuilder struct {
+	fieldType string
+	tagCount  int
+
+	names      []string
+	parents    []int
+	tagValues  [][]string
+	attributes []fieldmap.Attribute
+	stableIDs  []uint32
+	mapNodes   []int
+}
+
+func (b *tablesBuilder) appendField(
+	parent int, name string, tagValues []string, attrs fieldmap.Attribute, stableID uint32,
+) int {
+	if tagValues == nil {
+		tagValues = make([]string, b.tagCount)
+	}
+
+	b.names = append(b.names, name)
+	b.parents = append(b.parents, parent)
+	b.tagValues = append(b.tagValues, tagValues)
+	b.attributes = append(b.attributes, attrs)
+	b.stableIDs = append(b.stableIDs, stableID)
+	return len(b.names)
+}
+
+// visitStruct returns the literal of the mapping struct
+func (b *tablesBuilder) visitStruct(
+	node *structNode, parent int, field *fieldNode, fullFieldName string, attrs fieldmap.Attribute, stableID uint32,
+) (string, error) {
+	var root int
+	if field == nil {
+		root = b.appendField(parent, "", nil, attrs, stableID)
+	} else {
+		root = b.appendField(parent, field.name, field.tagValues, attrs, stableID)
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%s{\n%s: %d,\n", node.mappingName, node.rootName, root)
+
+	for _, f := range node.fields {
+		name := f.name
+		if len(fullFieldName) > 0 {
+			name = fullFieldName + "." + f.name
+		}
+
+		options, err := parseFieldOptions(name, f.options)
+		if err != nil {
+			return "", err
+		}
+		fieldAttrs := attrs | options.attributes
+
+		switch {
+		case f.nested != nil:
+			literal, err := b.visitStruct(f.nested, root, f, name, fieldAttrs, options.stableID)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&buf, "%s: %s,\n", f.name, literal)
+
+		case f.mapNode:
+			mapRoot := b.appendField(root, f.name, f.tagValues, fieldAttrs, options.stableID)
+
+			anyTags := make([]string, b.tagCount)
+			for i := range anyTags {
+				anyTags[i] = fieldmap.AnyKeyName
+			}
+			anyField := b.appendField(mapRoot, fieldmap.AnyKeyName, anyTags, fieldAttrs, 0)
+
+			b.mapNodes = append(b.mapNodes, mapRoot)
+			fmt.Fprintf(&buf, "%s: fieldmap.MapNode[%s]{Root: %d, Any: %d},\n", f.name, b.fieldType, mapRoot, anyField)
+
+		default:
+			ordinal := b.appendField(root, f.name, f.tagValues, fieldAttrs, options.stableID)
+			fmt.Fprintf(&buf, "%s: %d,\n", f.name, ordinal)
+		}
+	}
+
+	buf.WriteString("}")
+	return buf.String(), nil
+}
+
+func attributeLiteral(attr fieldmap.Attribute) string {
+	if attr == 0 {
+		return "0"
+	}
+	var parts []string
+	for _, name := range attr.Names() {
+		parts = append(parts, "fieldmap.Attribute"+strings.ToUpper(name[:1])+name[1:])
+	}
+	return strings.Join(parts, " | ")
+}
+
+func joinValues[V any](values []V, format func(v V) string) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, format(v))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func hasNonZero[V comparable](values []V) bool {
+	var empty V
+	for _, v := range values {
+		if v != empty {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) writeTables(node *structNode) error {
+	b := &tablesBuilder{
+		fieldType: g.conf.FieldType,
+		tagCount:  len(g.conf.Tags),
+	}
+	mapping, err := b.visitStruct(node, 0, nil, "", 0, 0)
+	if err != nil {
+		return err
+	}
+
+	name := node.mappingName + TablesSuffix
+	fieldType := g.conf.FieldType
+
+	fmt.Fprintf(&g.body, "// %s contains the FieldMap of %s, for fieldmap.NewFromTables\n", name, node.mappingName)
+	fmt.Fprintf(&g.body, "var %s = fieldmap.Tables[%s, %s]{\n", name, fieldType, node.mappingName)
+	fmt.Fprintf(&g.body, "Mapping: %s,\n\n", mapping)
+
+	if len(g.conf.Tags) > 0 {
+		fmt.Fprintf(&g.body, "StructTags: []string{%s},\n\n", joinValues(g.conf.Tags, strconv.Quote))
+	}
+
+	fmt.Fprintf(&g.body, "Names: []string{%s},\n", joinValues(b.names, strconv.Quote))
+	fmt.Fprintf(&g.body, "Parents: []%s{%s},\n", fieldType, joinValues(b.parents, strconv.Itoa))
+
+	if len(g.conf.Tags) > 0 {
+		fmt.Fprintf(&g.body, "Tags: map[string][]string{\n")
+		for i, tag := range g.conf.Tags {
+			values := make([]string, 0, len(b.tagValues))
+			for _, fieldValues := range b.tagValues {
+				values = append(values, fieldValues[i])
+			}
+			fmt.Fprintf(&g.body, "%s: {%s},\n", strconv.Quote(tag), joinValues(values, strconv.Quote))
+		}
+		fmt.Fprintf(&g.body, "},\n")
+	}
+
+	if hasNonZero(b.attributes) {
+		fmt.Fprintf(&g.body, "\nAttributes: []fieldmap.Attribute{\n")
+		for _, attr := range b.attributes {
+			fmt.Fprintf(&g.body, "%s,\n", attributeLiteral(attr))
+		}
+		fmt.Fprintf(&g.body, "},\n")
+	}
+	if hasNonZero(b.stableIDs) {
+		fmt.Fprintf(&g.body, "\nStableIDs: []uint32{%s},\n", joinValues(b.stableIDs, func(id uint32) string {
+			return strconv.FormatUint(uint64(id), 10)
+		}))
+	}
+
+	if len(b.mapNodes) > 0 {
+		fmt.Fprintf(&g.body, "\nMapNodes: []%s{%s},\n", fieldType, joinValues(b.mapNodes, strconv.Itoa))
+	}
+	fmt.Fprintf(&g.body, "}\n\n")
+	return nil
+}
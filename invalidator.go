@@ -0,0 +1,173 @@
+package fieldmap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Invalidator finds the cache keys to evict for changed source fields of a Mapper.
+// Cache key templates are registered against destination fields, e.g. "product:{id}:detail",
+// placeholders are replaced by identifiers of the changed entity.
+//
+// A template registered against a field is affected by a change of the field, of its ancestors
+// and of its descendants, i.e. a template registered against a struct field covers the whole subtree.
+// Safe for concurrent use.
+type Invalidator[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]] struct {
+	mapper   *Mapper[F1, T1, F2, T2]
+	parentOf func(field F2) F2
+
+	mut       sync.RWMutex
+	templates []invalidatorTemplate[F2]
+}
+
+type invalidatorTemplate[F Field] struct {
+	template keyTemplate
+	fields   []F
+}
+
+// keyTemplate is a parsed cache key template, placeholders[i] is between literals[i] and literals[i+1]
+type keyTemplate struct {
+	text         string
+	literals     []string
+	placeholders []string
+}
+
+func parseKeyTemplate(text string) (keyTemplate, error) {
+	t := keyTemplate{text: text}
+
+	rest := text
+	for {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			t.literals = append(t.literals, rest)
+			return t, nil
+		}
+		if rest[open] == '}' {
+			return t, fmt.Errorf("unexpected '}' in cache key template %q", text)
+		}
+
+		closing := strings.IndexAny(rest[open+1:], "{}")
+		if closing < 0 || rest[open+1+closing] != '}' {
+			return t, fmt.Errorf("unclosed '{' in cache key template %q", text)
+		}
+		name := rest[open+1 : open+1+closing]
+		if len(name) == 0 {
+			return t, fmt.Errorf("empty placeholder in cache key template %q", text)
+		}
+
+		t.literals = append(t.literals, rest[:open])
+		t.placeholders = append(t.placeholders, name)
+		rest = rest[open+1+closing+1:]
+	}
+}
+
+func (t keyTemplate) render(ids map[string]string) (string, error) {
+	var buf strings.Builder
+	for i, literal := range t.literals {
+		buf.WriteString(literal)
+		if i >= len(t.placeholders) {
+			break
+		}
+		name := t.placeholders[i]
+		value, ok := ids[name]
+		if !ok {
+			return "", fmt.Errorf("missing identifier %q for cache key template %q", name, t.text)
+		}
+		buf.WriteString(value)
+	}
+	return buf.String(), nil
+}
+
+// NewInvalidator creates an Invalidator for the Mapper, dest is the destination FieldMap of the Mapper
+func NewInvalidator[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]](
+	mapper *Mapper[F1, T1, F2, T2], dest *FieldMap[F2, T2],
+) *Invalidator[F1, T1, F2, T2] {
+	return &Invalidator[F1, T1, F2, T2]{
+		mapper:   mapper,
+		parentOf: dest.mappingParentOf,
+	}
+}
+
+// Register registers a cache key template against destination fields, placeholders are written as {name}.
+// Panics if the template is invalid or no field is specified.
+func (v *Invalidator[F1, T1, F2, T2]) Register(template string, fields ...F2) {
+	if len(fields) == 0 {
+		panic(fmt.Sprintf("missing fields for cache key template %q", template))
+	}
+	t, err := parseKeyTemplate(template)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	v.templates = append(v.templates, invalidatorTemplate[F2]{
+		template: t,
+		fields:   fields,
+	})
+}
+
+func (v *Invalidator[F1, T1, F2, T2]) isAncestorOrSelf(ancestor F2, field F2) bool {
+	var empty F2
+	for field != empty {
+		if field == ancestor {
+			return true
+		}
+		field = v.parentOf(field)
+	}
+	return false
+}
+
+func (v *Invalidator[F1, T1, F2, T2]) isAffected(t invalidatorTemplate[F2], destFields []F2) bool {
+	for _, registered := range t.fields {
+		for _, field := range destFields {
+			if v.isAncestorOrSelf(registered, field) || v.isAncestorOrSelf(field, registered) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// KeysToEvict is KeysToEvictCtx with context.Background()
+func (v *Invalidator[F1, T1, F2, T2]) KeysToEvict(changed []F1, ids map[string]string) ([]string, error) {
+	return v.KeysToEvictCtx(context.Background(), changed, ids)
+}
+
+// KeysToEvictCtx returns the deduplicated cache keys of the templates affected by the destination fields
+// that the changed source fields are mapped to, see Mapper.FindMappedFieldsCtx.
+// Keys are in the order of Register. Returns an error if an identifier of an affected template is missing in ids.
+func (v *Invalidator[F1, T1, F2, T2]) KeysToEvictCtx(
+	ctx context.Context, changed []F1, ids map[string]string,
+) ([]string, error) {
+	destFields := v.mapper.FindMappedFieldsCtx(ctx, changed)
+	if len(destFields) == 0 {
+		return nil, nil
+	}
+
+	v.mut.RLock()
+	defer v.mut.RUnlock()
+
+	var result []string
+	resultSet := map[string]emptyStruct{}
+
+	for _, t := range v.templates {
+		if !v.isAffected(t, destFields) {
+			continue
+		}
+
+		key, err := t.template.render(ids)
+		if err != nil {
+			return nil, err
+		}
+		if _, existed := resultSet[key]; existed {
+			continue
+		}
+		resultSet[key] = emptyStruct{}
+		result = append(result, key)
+	}
+	return result, nil
+}
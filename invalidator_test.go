@@ -0,0 +1,158 @@
+package fieldmap
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestParseKeyTemplate(t *testing.T) {
+	tmpl, err := parseKeyTemplate("product:{id}:seller:{seller_id}")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, keyTemplate{
+		text:         "product:{id}:seller:{seller_id}",
+		literals:     []string{"product:", ":seller:", ""},
+		placeholders: []string{"id", "seller_id"},
+	}, tmpl)
+
+	key, err := tmpl.render(map[string]string{"id": "12", "seller_id": "3", "other": "4"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "product:12:seller:3", key)
+
+	_, err = tmpl.render(map[string]string{"id": "12"})
+	assert.Equal(t, errors.New(`missing identifier "seller_id" for cache key template "product:{id}:seller:{seller_id}"`), err)
+
+	tmpl, err = parseKeyTemplate("products")
+	assert.Equal(t, nil, err)
+	key, err = tmpl.render(nil)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "products", key)
+
+	_, err = parseKeyTemplate("product:{id")
+	assert.Equal(t, errors.New(`unclosed '{' in cache key template "product:{id"`), err)
+
+	_, err = parseKeyTemplate("product:{i{d}")
+	assert.Equal(t, errors.New(`unclosed '{' in cache key template "product:{i{d}"`), err)
+
+	_, err = parseKeyTemplate("product:id}")
+	assert.Equal(t, errors.New(`unexpected '}' in cache key template "product:id}"`), err)
+
+	_, err = parseKeyTemplate("product:{}")
+	assert.Equal(t, errors.New(`empty placeholder in cache key template "product:{}"`), err)
+}
+
+func newInvalidatorForTest() (
+	*Invalidator[sourceField, sourceDataComplex, destField, destDataComplex],
+	sourceDataComplex, destDataComplex,
+) {
+	sourceFm := New[sourceField, sourceDataComplex]()
+	destFm := New[destField, destDataComplex]()
+
+	source := sourceFm.GetMapping()
+	dest := destFm.GetMapping()
+
+	m := NewMapper(
+		sourceFm, destFm,
+		WithSimpleMapping(sourceFm, destFm,
+			NewMapping(source.Sku, dest.Info.Sku, dest.SearchText),
+			NewMapping(source.Name, dest.Info.Name),
+			NewMapping(source.Body, dest.Detail.Body),
+			NewMapping(source.Seller.Root, dest.Root),
+			NewMapping(source.ImageURL, dest.Detail.Body).When(isTenant("a")),
+		),
+	)
+
+	v := NewInvalidator(m, destFm)
+	v.Register("product:{id}:info", dest.Info.Root)
+	v.Register("product:{id}:detail", dest.Detail.Root)
+	v.Register("product:{id}:sku", dest.Info.Sku)
+	v.Register("search:{id}", dest.SearchText, dest.Info.Name)
+	v.Register("product:{id}:info", dest.Info.Name)
+
+	return v, source, dest
+}
+
+func TestInvalidator(t *testing.T) {
+	ids := map[string]string{"id": "21"}
+
+	t.Run("subtrees", func(t *testing.T) {
+		v, source, _ := newInvalidatorForTest()
+
+		keys, err := v.KeysToEvict([]sourceField{source.Sku}, ids)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"product:21:info", "product:21:sku", "search:21"}, keys)
+
+		keys, err = v.KeysToEvict([]sourceField{source.Name, source.Body}, ids)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"product:21:info", "product:21:detail", "search:21"}, keys)
+	})
+
+	t.Run("ancestor of registered fields", func(t *testing.T) {
+		v, source, _ := newInvalidatorForTest()
+
+		keys, err := v.KeysToEvict([]sourceField{source.Seller.Name}, ids)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"product:21:info", "product:21:detail", "product:21:sku", "search:21"}, keys)
+	})
+
+	t.Run("conditional rules", func(t *testing.T) {
+		v, source, _ := newInvalidatorForTest()
+
+		keys, err := v.KeysToEvictCtx(withTenant("a"), []sourceField{source.ImageURL}, ids)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"product:21:detail"}, keys)
+
+		keys, err = v.KeysToEvictCtx(withTenant("b"), []sourceField{source.ImageURL}, ids)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(keys))
+	})
+
+	t.Run("missing identifier", func(t *testing.T) {
+		v, source, dest := newInvalidatorForTest()
+		v.Register("seller:{seller_id}:products", dest.Detail.Body)
+
+		keys, err := v.KeysToEvict([]sourceField{source.Body}, ids)
+		assert.Equal(t, errors.New(`missing identifier "seller_id" for cache key template "seller:{seller_id}:products"`), err)
+		assert.Equal(t, 0, len(keys))
+
+		keys, err = v.KeysToEvict([]sourceField{source.Name}, ids)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"product:21:info", "search:21"}, keys)
+	})
+
+	t.Run("invalid register", func(t *testing.T) {
+		v, _, dest := newInvalidatorForTest()
+
+		assert.PanicsWithValue(t, `missing fields for cache key template "product:{id}"`, func() {
+			v.Register("product:{id}")
+		})
+		assert.PanicsWithValue(t, `unclosed '{' in cache key template "product:{id"`, func() {
+			v.Register("product:{id", dest.Info.Root)
+		})
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		v, source, dest := newInvalidatorForTest()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				v.Register(fmt.Sprintf("body:{id}:%d", i), dest.Detail.Body)
+			}(i)
+			go func() {
+				defer wg.Done()
+				_, err := v.KeysToEvict([]sourceField{source.Body}, ids)
+				assert.Equal(t, nil, err)
+			}()
+		}
+		wg.Wait()
+
+		keys, err := v.KeysToEvict([]sourceField{source.Body}, ids)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 9, len(keys))
+	})
+}
@@ -0,0 +1,132 @@
+package fieldmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// JSONSchemaDraft is the value of the $schema keyword of documents generated by JSONSchema
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchemaOrdinalKey is the extension keyword containing the ordinal of a field
+const JSONSchemaOrdinalKey = "x-fieldmap-ordinal"
+
+// JSONSchemaAttributesKey is the extension keyword containing the attribute names of a field
+const JSONSchemaAttributesKey = "x-fieldmap-attributes"
+
+// JSONSchema generates a JSON Schema document of the field tree.
+// Property names are values of the struct tag, or field names if tag is empty.
+// Leaf fields only have the type keyword when the FieldMap is bound to a data struct (NewFromData).
+func (f *FieldMap[F, T]) JSONSchema(tag string) ([]byte, error) {
+	if len(tag) > 0 {
+		if _, ok := f.structTags[tag]; !ok {
+			return nil, fmt.Errorf("struct tag %q is not configured", tag)
+		}
+	}
+
+	schema := f.jsonSchemaOf(f.structRoot, tag)
+	schema["$schema"] = JSONSchemaDraft
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func (f *FieldMap[F, T]) jsonPropertyName(field F, tag string) string {
+	if len(tag) == 0 {
+		return f.GetFieldName(field)
+	}
+	return f.GetStructTag(tag, field)
+}
+
+func (f *FieldMap[F, T]) jsonSchemaOf(field F, tag string) map[string]any {
+	schema := map[string]any{
+		JSONSchemaOrdinalKey: f.indexOf(field) + 1,
+	}
+
+	attrs := f.GetAttributes(field)
+	if attrs&AttributeReadonly != 0 {
+		schema["readOnly"] = true
+	}
+	if attrs&AttributeDeprecated != 0 {
+		schema["deprecated"] = true
+	}
+	if attrs != 0 {
+		schema[JSONSchemaAttributesKey] = attrs.Names()
+	}
+
+	if !f.IsStruct(field) {
+		if jsonType, format := jsonSchemaTypeOf(f.GetDataType(field)); len(jsonType) > 0 {
+			schema["type"] = jsonType
+			if len(format) > 0 {
+				schema["format"] = format
+			}
+		}
+		return schema
+	}
+
+	schema["type"] = "object"
+
+	var anyField F
+	if data, ok := f.mapNodes[field]; ok {
+		anyField = data.anyField
+		schema["additionalProperties"] = f.jsonSchemaOf(anyField, tag)
+	}
+
+	properties := map[string]any{}
+	var required []string
+	for _, child := range f.ChildrenOf(field) {
+		if child == anyField {
+			continue
+		}
+		name := f.jsonPropertyName(child, tag)
+		properties[name] = f.jsonSchemaOf(child, tag)
+
+		if f.HasAttribute(child, AttributeRequired) {
+			required = append(required, name)
+		}
+	}
+	if len(properties) > 0 {
+		schema["properties"] = properties
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func jsonSchemaTypeOf(t reflect.Type) (jsonType string, format string) {
+	if t == nil {
+		return "", ""
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return "string", "date-time"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", ""
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", ""
+	case reflect.Float32, reflect.Float64:
+		return "number", ""
+	case reflect.String:
+		return "string", ""
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string", "byte"
+		}
+		return "array", ""
+	case reflect.Map, reflect.Struct:
+		return "object", ""
+	default:
+		return "", ""
+	}
+}
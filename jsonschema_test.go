@@ -0,0 +1,103 @@
+package fieldmap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFieldMap_JSONSchema(t *testing.T) {
+	t.Run("mapping struct", func(t *testing.T) {
+		fm := New[field, productWithAttrs]()
+
+		data, err := fm.JSONSchema("")
+		assert.Equal(t, nil, err)
+		assert.JSONEq(t, `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "x-fieldmap-ordinal": 1,
+  "required": ["Sku", "Name"],
+  "properties": {
+    "ID": {"x-fieldmap-ordinal": 2, "readOnly": true, "x-fieldmap-attributes": ["readonly"]},
+    "Sku": {"x-fieldmap-ordinal": 3, "x-fieldmap-attributes": ["required", "immutable"]},
+    "Name": {"x-fieldmap-ordinal": 4, "x-fieldmap-attributes": ["required"]},
+    "OldName": {"x-fieldmap-ordinal": 5, "deprecated": true, "x-fieldmap-attributes": ["deprecated"]},
+    "Seller": {
+      "x-fieldmap-ordinal": 6, "type": "object", "deprecated": true, "x-fieldmap-attributes": ["deprecated"],
+      "required": ["Name"],
+      "properties": {
+        "ID": {"x-fieldmap-ordinal": 7, "deprecated": true, "x-fieldmap-attributes": ["immutable", "deprecated"]},
+        "Name": {"x-fieldmap-ordinal": 8, "deprecated": true, "x-fieldmap-attributes": ["required", "deprecated"]}
+      }
+    },
+    "Stats": {
+      "x-fieldmap-ordinal": 9, "type": "object", "readOnly": true, "x-fieldmap-attributes": ["readonly"],
+      "properties": {
+        "ID": {"x-fieldmap-ordinal": 10, "readOnly": true, "x-fieldmap-attributes": ["readonly"]},
+        "Name": {"x-fieldmap-ordinal": 11, "readOnly": true, "x-fieldmap-attributes": ["readonly"]},
+        "Logo": {"x-fieldmap-ordinal": 12, "readOnly": true, "x-fieldmap-attributes": ["readonly"]},
+        "Attr": {
+          "x-fieldmap-ordinal": 13, "type": "object", "readOnly": true, "x-fieldmap-attributes": ["readonly"],
+          "properties": {
+            "Code": {"x-fieldmap-ordinal": 14, "readOnly": true, "x-fieldmap-attributes": ["readonly"]},
+            "Name": {"x-fieldmap-ordinal": 15, "readOnly": true, "x-fieldmap-attributes": ["readonly"]}
+          }
+        }
+      }
+    },
+    "ImageURL": {"x-fieldmap-ordinal": 16}
+  }
+}`, string(data))
+	})
+
+	t.Run("bound to data struct", func(t *testing.T) {
+		fm := NewFromData[field, Product](WithStructTags("json"))
+		fm.RegisterKey(fm.MapNode("Attributes"), "color")
+
+		data, err := fm.JSONSchema("json")
+		assert.Equal(t, nil, err)
+		assert.JSONEq(t, `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "x-fieldmap-ordinal": 1,
+  "required": ["name"],
+  "properties": {
+    "sku": {"x-fieldmap-ordinal": 2, "type": "string"},
+    "name": {"x-fieldmap-ordinal": 3, "type": "string", "x-fieldmap-attributes": ["required"]},
+    "seller": {
+      "x-fieldmap-ordinal": 4, "type": "object",
+      "properties": {
+        "id": {"x-fieldmap-ordinal": 5, "type": "integer"},
+        "name": {"x-fieldmap-ordinal": 6, "type": "string"},
+        "logo": {"x-fieldmap-ordinal": 7, "type": "string"},
+        "attr": {
+          "x-fieldmap-ordinal": 8, "type": "object",
+          "properties": {
+            "code": {"x-fieldmap-ordinal": 9, "type": "string"},
+            "name": {"x-fieldmap-ordinal": 10, "type": "string"}
+          }
+        }
+      }
+    },
+    "attributes": {
+      "x-fieldmap-ordinal": 11, "type": "object",
+      "additionalProperties": {"x-fieldmap-ordinal": 12, "type": "string"},
+      "properties": {
+        "color": {"x-fieldmap-ordinal": 15, "type": "string"}
+      }
+    },
+    "createdAt": {
+      "x-fieldmap-ordinal": 13, "type": "string", "format": "date-time",
+      "readOnly": true, "x-fieldmap-attributes": ["readonly"]
+    },
+    "price": {"x-fieldmap-ordinal": 14, "type": "object"}
+  }
+}`, string(data))
+	})
+
+	t.Run("tag not configured", func(t *testing.T) {
+		fm := New[field, productData]()
+
+		_, err := fm.JSONSchema("json")
+		assert.Equal(t, `struct tag "json" is not configured`, err.Error())
+	})
+}
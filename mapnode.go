@@ -0,0 +1,158 @@
+package fieldmap
+
+import (
+	"fmt"
+	"reflect"
+	"unicode"
+)
+
+// AnyKeyName is the field name and struct tag value of the MapNode.Any field
+const AnyKeyName = "*"
+
+// MapNode is a node of a mapping struct whose keys are only known at runtime,
+// e.g. for a data field of type map[string]string.
+// Keys are added with FieldMap.RegisterKey.
+// Mapping rules of the Any field apply to every registered key.
+type MapNode[F Field] struct {
+	Root F
+	Any  F
+}
+
+func (MapNode[F]) isMapNode() {}
+
+type mapNodeData[F Field] struct {
+	anyField F
+	keys     map[string]F
+}
+
+func (*FieldMap[F, T]) getMapNodeType() reflect.Type {
+	return reflect.TypeOf(MapNode[F]{})
+}
+
+func (f *FieldMap[F, T]) traverseMapNode(
+	val reflect.Value, parent F, fieldName string,
+	structTags map[string]string, attrs Attribute,
+) MapNode[F] {
+	node := val.Addr().Interface().(*MapNode[F])
+	*node = f.appendMapNode(parent, fieldName, structTags, attrs)
+	return *node
+}
+
+func (f *FieldMap[F, T]) appendMapNode(
+	parent F, fieldName string,
+	structTags map[string]string, attrs Attribute,
+) MapNode[F] {
+	rootField := f.appendField(parent, fieldName, structTags, attrs)
+
+	anyTags := map[string]string{}
+	for _, tag := range f.options.structTags {
+		anyTags[tag] = AnyKeyName
+	}
+	anyField := f.appendField(rootField, AnyKeyName, anyTags, attrs)
+
+	f.mapNodes[rootField] = &mapNodeData[F]{
+		anyField: anyField,
+		keys:     map[string]F{},
+	}
+
+	return MapNode[F]{
+		Root: rootField,
+		Any:  anyField,
+	}
+}
+
+func (f *FieldMap[F, T]) getMapNodeData(node MapNode[F]) *mapNodeData[F] {
+	var empty F
+	if node.Root == empty {
+		panic("map node is not initialized")
+	}
+	data, ok := f.mapNodes[node.Root]
+	if !ok || data.anyField != node.Any {
+		panic(fmt.Sprintf("field %q is not a map node", f.GetFullFieldName(node.Root)))
+	}
+	return data
+}
+
+// isValidKey checks that the key only contains letters, digits, '_' and '-',
+// other characters can conflict with separators of paths, such as '.' and ','
+func isValidKey(key string) bool {
+	if len(key) == 0 {
+		return false
+	}
+	for _, r := range key {
+		if r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// RegisterKey adds a key to a map node and returns its field.
+// Keys can only contain letters, digits, '_' and '-'.
+// The new field is appended after all existing fields, so ordinals of other fields are not changed.
+// Registering an existing key returns the same field.
+// It is NOT safe to call concurrently with other methods, should be called at initialization.
+func (f *FieldMap[F, T]) RegisterKey(node MapNode[F], key string) F {
+	data := f.getMapNodeData(node)
+
+	if !isValidKey(key) {
+		panic(fmt.Sprintf("invalid key %q for map node %q", key, f.GetFullFieldName(node.Root)))
+	}
+
+	field, existed := data.keys[key]
+	if existed {
+		return field
+	}
+
+	keyTags := map[string]string{}
+	for _, tag := range f.options.structTags {
+		keyTags[tag] = key
+	}
+	field = f.appendField(node.Root, key, keyTags, f.attributes[f.indexOf(node.Root)])
+
+	data.keys[key] = field
+	f.mapKeyOf[field] = node.Root
+	return field
+}
+
+// GetKey returns the field of a registered key
+func (f *FieldMap[F, T]) GetKey(node MapNode[F], key string) (F, bool) {
+	field, ok := f.getMapNodeData(node).keys[key]
+	return field, ok
+}
+
+// IsMapKey checks whether the field is a registered key of a map node
+func (f *FieldMap[F, T]) IsMapKey(field F) bool {
+	_, ok := f.mapKeyOf[field]
+	return ok
+}
+
+// mappingParentOf is similar to ParentOf, except that the parent of a registered key is the Any field
+func (f *FieldMap[F, T]) mappingParentOf(field F) F {
+	root, ok := f.mapKeyOf[field]
+	if ok {
+		return f.mapNodes[root].anyField
+	}
+	return f.ParentOf(field)
+}
+
+// mapKeyName returns the root of the map node and the key of a registered key
+func (f *FieldMap[F, T]) mapKeyName(field F) (F, string, bool) {
+	root, ok := f.mapKeyOf[field]
+	if !ok {
+		var empty F
+		return empty, "", false
+	}
+	return root, f.GetFieldName(field), true
+}
+
+// mustGetKeyOf returns the registered key of the map node with the root field, panics if it is not registered
+func (f *FieldMap[F, T]) mustGetKeyOf(root F, key string) F {
+	if data, ok := f.mapNodes[root]; ok {
+		if field, ok := data.keys[key]; ok {
+			return field
+		}
+	}
+	panic(fmt.Sprintf("key %q is not registered for map node %q", key, f.GetFullFieldName(root)))
+}
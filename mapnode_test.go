@@ -0,0 +1,188 @@
+package fieldmap
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type catalogData struct {
+	Root       field
+	Sku        field          `json:"sku"`
+	Attributes MapNode[field] `json:"attributes"`
+	Name       field          `json:"name"`
+}
+
+func (d catalogData) GetRoot() field { return d.Root }
+
+func TestMapNode(t *testing.T) {
+	t.Run("ordinals", func(t *testing.T) {
+		fm := New[field, catalogData]()
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, field(1), p.Root)
+		assert.Equal(t, field(2), p.Sku)
+		assert.Equal(t, field(3), p.Attributes.Root)
+		assert.Equal(t, field(4), p.Attributes.Any)
+		assert.Equal(t, field(5), p.Name)
+
+		assert.Equal(t, true, fm.IsStruct(p.Attributes.Root))
+		assert.Equal(t, []field{p.Attributes.Any}, fm.ChildrenOf(p.Attributes.Root))
+		assert.Equal(t, []field{p.Sku, p.Attributes.Root, p.Name}, fm.ChildrenOf(p.Root))
+
+		assert.Equal(t, "Attributes.*", fm.GetFullFieldName(p.Attributes.Any))
+	})
+
+	t.Run("register keys", func(t *testing.T) {
+		fm := New[field, catalogData](WithStructTags("json"))
+
+		p := fm.GetMapping()
+
+		color := fm.RegisterKey(p.Attributes, "color")
+		size := fm.RegisterKey(p.Attributes, "size")
+
+		assert.Equal(t, field(6), color)
+		assert.Equal(t, field(7), size)
+		assert.Equal(t, color, fm.RegisterKey(p.Attributes, "color"))
+
+		assert.Equal(t, []field{p.Attributes.Any, color, size}, fm.ChildrenOf(p.Attributes.Root))
+		assert.Equal(t, p.Attributes.Root, fm.ParentOf(color))
+
+		assert.Equal(t, "color", fm.GetFieldName(color))
+		assert.Equal(t, "Attributes.color", fm.GetFullFieldName(color))
+		assert.Equal(t, "attributes.size", fm.GetFullStructTag("json", size))
+		assert.Equal(t, "attributes.*", fm.GetFullStructTag("json", p.Attributes.Any))
+
+		assert.Equal(t, true, fm.IsMapKey(color))
+		assert.Equal(t, false, fm.IsMapKey(p.Attributes.Any))
+
+		key, ok := fm.GetKey(p.Attributes, "size")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, size, key)
+
+		_, ok = fm.GetKey(p.Attributes, "weight")
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("panics with invalid key", func(t *testing.T) {
+		fm := New[field, catalogData]()
+
+		p := fm.GetMapping()
+
+		assert.PanicsWithValue(t, `invalid key "*" for map node "Attributes"`, func() {
+			fm.RegisterKey(p.Attributes, AnyKeyName)
+		})
+		for _, key := range []string{"", "a.b", "a,b", "a b", "[a]", "a*"} {
+			assert.PanicsWithValue(t, fmt.Sprintf("invalid key %q for map node \"Attributes\"", key), func() {
+				fm.RegisterKey(p.Attributes, key)
+			})
+		}
+
+		assert.Equal(t, "Attributes.size_XL-2", fm.GetFullFieldName(fm.RegisterKey(p.Attributes, "size_XL-2")))
+		assert.Equal(t, "Attributes.màu", fm.GetFullFieldName(fm.RegisterKey(p.Attributes, "màu")))
+	})
+
+	t.Run("panics with not a map node", func(t *testing.T) {
+		fm := New[field, catalogData]()
+
+		p := fm.GetMapping()
+
+		assert.PanicsWithValue(t, `field "Sku" is not a map node`, func() {
+			fm.RegisterKey(MapNode[field]{Root: p.Sku, Any: p.Name}, "color")
+		})
+	})
+}
+
+type sourceCatalog struct {
+	Root       sourceField
+	Sku        sourceField
+	Attributes MapNode[sourceField]
+}
+
+func (d sourceCatalog) GetRoot() sourceField { return d.Root }
+
+func TestMapNode_Mapping(t *testing.T) {
+	sourceFm := New[sourceField, sourceCatalog]()
+	destFm := New[destField, destDataComplex]()
+
+	source := sourceFm.GetMapping()
+	dest := destFm.GetMapping()
+
+	color := sourceFm.RegisterKey(source.Attributes, "color")
+	size := sourceFm.RegisterKey(source.Attributes, "size")
+
+	m := NewMapper(
+		sourceFm, destFm,
+		WithSimpleMapping(sourceFm, destFm,
+			NewMapping(source.Attributes.Root, dest.Detail.Root),
+			NewMapping(source.Attributes.Any, dest.SearchText),
+			NewMapping(color, dest.Info.Name),
+		),
+	)
+
+	assert.Equal(t, []destField{dest.Info.Name}, m.FindMappedFields([]sourceField{color}))
+	assert.Equal(t, []destField{dest.SearchText}, m.FindMappedFields([]sourceField{size}))
+	assert.Equal(t, []destField{dest.Detail.Root}, m.FindMappedFields([]sourceField{source.Attributes.Root}))
+
+	weight := sourceFm.RegisterKey(source.Attributes, "weight")
+	assert.Equal(t, []destField{dest.SearchText}, m.FindMappedFields([]sourceField{weight}))
+}
+
+type sourceProductCatalog struct {
+	Root    sourceField
+	Name    sourceField
+	Catalog sourceCatalog
+	Body    sourceField
+}
+
+func (d sourceProductCatalog) GetRoot() sourceField { return d.Root }
+
+func (d sourceProductCatalog) GetCatalog() sourceCatalog { return d.Catalog }
+
+func TestMapNode_InheritMapping(t *testing.T) {
+	subSourceFm := New[sourceField, sourceCatalog]()
+	subDestFm := New[destField, destDetail]()
+
+	subSource := subSourceFm.GetMapping()
+	subDest := subDestFm.GetMapping()
+
+	subColor := subSourceFm.RegisterKey(subSource.Attributes, "color")
+
+	subMapper := NewMapper(
+		subSourceFm, subDestFm,
+		WithSimpleMapping(subSourceFm, subDestFm,
+			NewMapping(subSource.Sku, subDest.Root),
+			NewMapping(subColor, subDest.Body),
+		),
+	)
+
+	sourceFm := New[sourceField, sourceProductCatalog]()
+	destFm := New[destField, destDataComplex]()
+
+	source := sourceFm.GetMapping()
+	dest := destFm.GetMapping()
+
+	newMapper := func() *Mapper[sourceField, sourceProductCatalog, destField, destDataComplex] {
+		return NewMapper(
+			sourceFm, destFm,
+			WithInheritMapping(sourceFm, destFm, subMapper,
+				sourceProductCatalog.GetCatalog,
+				destDataComplex.GetDetail,
+			),
+		)
+	}
+
+	assert.PanicsWithValue(t, `key "color" is not registered for map node "Catalog.Attributes"`, func() {
+		newMapper()
+	})
+
+	color := sourceFm.RegisterKey(source.Catalog.Attributes, "color")
+	m := newMapper()
+
+	assert.Equal(t, "Catalog.Attributes.color", sourceFm.GetFullFieldName(color))
+
+	assert.Equal(t, []destField{dest.Detail.Body}, m.FindMappedFields([]sourceField{color}))
+	assert.Equal(t, []destField{dest.Detail.Root}, m.FindMappedFields([]sourceField{source.Catalog.Sku}))
+	assert.Equal(t, 0, len(m.FindMappedFields([]sourceField{source.Body})))
+}
@@ -1,18 +1,108 @@
 package fieldmap
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // Mapper ...
 type Mapper[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]] struct {
 	parentOf func(source F1) F1
-	fieldMap map[F1][][]F2
+	fieldMap map[F1][]mappingAlternative[F2]
 	mappings []MappingData[F1, F2]
+
+	sourceName func(field F1) string
+	destName   func(field F2) string
+	destCosts  func() map[F2]int
+
+	sourceMapKey func(field F1) (F1, string, bool)
+	destMapKey   func(field F2) (F2, string, bool)
 }
 
 // MappingData ...
 type MappingData[F1, F2 Field] struct {
 	from   F1
 	toList []F2
+	expr   Expr[F2]
+
+	inherited bool
+	pattern   *Pattern
+	when      func(ctx context.Context) bool
+}
+
+type mappingAlternative[F2 Field] struct {
+	when func(ctx context.Context) bool
+
+	// expr is only used if it has conditions, otherwise its alternatives are precomputed in results
+	expr    *Expr[F2]
+	results [][]F2
+}
+
+// When returns a copy of the rule that is only used when the condition is true
+// for the context passed to FindMappedFieldsCtx.
+//
+// Conditions take a context.Context instead of a typed value, so that MappingData, Mapper and all options
+// do not need another type parameter, and rules of sub Mappers with conditions on different values can be
+// inherited together. Use ConditionOn and WithConditionValue for conditions on a typed value.
+func (m MappingData[F1, F2]) When(cond func(ctx context.Context) bool) MappingData[F1, F2] {
+	if cond == nil {
+		panic("nil condition of mapping")
+	}
+	m.when = cond
+	return m
+}
+
+// conditionKey is the context key of the value of type C for conditions created by ConditionOn
+type conditionKey[C any] struct{}
+
+// WithConditionValue returns a copy of ctx carrying the value for conditions created by ConditionOn[C]
+func WithConditionValue[C any](ctx context.Context, value C) context.Context {
+	return context.WithValue(ctx, conditionKey[C]{}, value)
+}
+
+// ConditionOn converts a condition on a value of type C to a condition for When.
+// The value is set by WithConditionValue, the condition is false if the context has no value of type C.
+func ConditionOn[C any](cond func(value C) bool) func(ctx context.Context) bool {
+	if cond == nil {
+		panic("nil condition of mapping")
+	}
+	return func(ctx context.Context) bool {
+		value, ok := ctx.Value(conditionKey[C]{}).(C)
+		if !ok {
+			return false
+		}
+		return cond(value)
+	}
+}
+
+// From returns the source field of the rule
+func (m MappingData[F1, F2]) From() F1 {
+	return m.from
+}
+
+// ToList returns all destination fields in the expression of the rule
+func (m MappingData[F1, F2]) ToList() []F2 {
+	return m.toList
+}
+
+// Expr returns the expression of destination fields of the rule
+func (m MappingData[F1, F2]) Expr() Expr[F2] {
+	return m.expr
+}
+
+// Inherited returns true if the rule is added by WithInheritMapping
+func (m MappingData[F1, F2]) Inherited() bool {
+	return m.inherited
+}
+
+// Conditional returns true if the rule or its expression has a condition set by When
+func (m MappingData[F1, F2]) Conditional() bool {
+	return m.when != nil || m.expr.conditional()
+}
+
+// Pattern returns the pattern that the rule is expanded from, nil if the rule is not created by WithPatternMapping
+func (m MappingData[F1, F2]) Pattern() *Pattern {
+	return m.pattern
 }
 
 // MappingOption ...
@@ -20,14 +110,34 @@ type MappingOption[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]] func(
 	mappings []MappingData[F1, F2],
 ) []MappingData[F1, F2]
 
-// NewMapping ...
+// NewMapping maps the source field to all the destination fields, the same as
+// NewMappingExpr(from, AllOf(Dest(toList[0]), Dest(toList[1]), ...)).
+// Several rules of the same source field are alternatives, the first matched one is used,
+// the same as a single rule with an AnyOf expression.
 func NewMapping[F1, F2 Field](
 	from F1, toList ...F2,
 ) MappingData[F1, F2] {
 	if len(toList) == 0 {
 		panic("missing destination fields")
 	}
-	return MappingData[F1, F2]{from: from, toList: toList}
+	return NewMappingExpr(from, destExpr(toList))
+}
+
+// NewMappingExpr maps the source field to the result of the expression, see Expr
+func NewMappingExpr[F1, F2 Field](from F1, expr Expr[F2]) MappingData[F1, F2] {
+	expr.mustValid()
+	return MappingData[F1, F2]{from: from, toList: expr.fields(), expr: expr}
+}
+
+func destExpr[F Field](toList []F) Expr[F] {
+	if len(toList) == 1 {
+		return Dest(toList[0])
+	}
+	exprs := make([]Expr[F], 0, len(toList))
+	for _, to := range toList {
+		exprs = append(exprs, Dest(to))
+	}
+	return AllOf(exprs...)
 }
 
 // WithSimpleMapping ...
@@ -40,7 +150,22 @@ func WithSimpleMapping[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]](
 	}
 }
 
-// WithInheritMapping ...
+// inheritField converts a field of a sub FieldMap to the field of the FieldMap containing it.
+// Registered keys are not in the range of ordinals of the sub struct, they are looked up by name.
+func inheritField[F Field](
+	field F, diff F,
+	mapKeyOf func(field F) (F, string, bool), getKey func(root F, key string) F,
+) F {
+	root, key, ok := mapKeyOf(field)
+	if ok {
+		return getKey(root+diff, key)
+	}
+	return field + diff
+}
+
+// WithInheritMapping adds the rules of a Mapper of sub structs.
+// Keys of the sub FieldMaps used by the rules must also be registered to the same map nodes of source and dest
+// before NewMapper, otherwise NewMapper panics.
 func WithInheritMapping[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2], SubT1 MapType[F1], SubT2 MapType[F2]](
 	source *FieldMap[F1, T1], dest *FieldMap[F2, T2],
 	inherit *Mapper[F1, SubT1, F2, SubT2],
@@ -50,15 +175,25 @@ func WithInheritMapping[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2], SubT
 		sourceDiff := sourceFunc(source.GetMapping()).GetRoot() - 1
 		destDiff := destFunc(dest.GetMapping()).GetRoot() - 1
 
+		convertDest := func(to F2) F2 {
+			return inheritField(to, destDiff, inherit.destMapKey, dest.mustGetKeyOf)
+		}
+
 		for _, subMapping := range inherit.mappings {
 			newToList := make([]F2, 0, len(subMapping.toList))
 			for _, to := range subMapping.toList {
-				newToList = append(newToList, to+destDiff)
+				newToList = append(newToList, convertDest(to))
 			}
+			newExpr := subMapping.expr.mapFields(convertDest)
 
 			mappings = append(mappings, MappingData[F1, F2]{
-				from:   subMapping.from + sourceDiff,
+				from:   inheritField(subMapping.from, sourceDiff, inherit.sourceMapKey, source.mustGetKeyOf),
 				toList: newToList,
+				expr:   newExpr,
+
+				inherited: true,
+				pattern:   subMapping.pattern,
+				when:      subMapping.when,
 			})
 		}
 		return mappings
@@ -72,7 +207,33 @@ func NewMapper[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]](
 	source *FieldMap[F1, T1], dest *FieldMap[F2, T2],
 	mappings ...MappingOption[F1, T1, F2, T2],
 ) *Mapper[F1, T1, F2, T2] {
-	fieldMap := map[F1][][]F2{}
+	var mappingDataList []MappingData[F1, F2]
+	for _, option := range mappings {
+		mappingDataList = option(mappingDataList)
+	}
+
+	m, err := newMapper(source, dest, mappingDataList)
+	if err != nil {
+		panic(err.Error())
+	}
+	return m
+}
+
+// mapperError is an invalid rule, index is the position of the rule in the list of rules
+type mapperError struct {
+	index   int
+	message string
+}
+
+func (e *mapperError) Error() string {
+	return e.message
+}
+
+func newMapper[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]](
+	source *FieldMap[F1, T1], dest *FieldMap[F2, T2],
+	mappingDataList []MappingData[F1, F2],
+) (*Mapper[F1, T1, F2, T2], error) {
+	fieldMap := map[F1][]mappingAlternative[F2]{}
 	dedupSets := map[F1]map[F2]emptyStruct{}
 
 	getDedupSet := func(source F1) map[F2]emptyStruct {
@@ -84,70 +245,156 @@ func NewMapper[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]](
 		return s
 	}
 
-	var mappingDataList []MappingData[F1, F2]
-	for _, option := range mappings {
-		mappingDataList = option(mappingDataList)
+	duplicatedError := func(index int, from F1, to F2) error {
+		return &mapperError{
+			index: index,
+			message: fmt.Sprintf(
+				"duplicated destination field %q for source field %q",
+				dest.GetFullFieldName(to),
+				source.GetFullFieldName(from),
+			),
+		}
 	}
 
-	for _, m := range mappingDataList {
+	for index, m := range mappingDataList {
+		if dup, ok := m.expr.findDuplicate(); ok {
+			return nil, duplicatedError(index, m.from, dup)
+		}
+
+		conditional := m.expr.conditional()
+
 		set := getDedupSet(m.from)
-		if len(m.toList) == 1 {
-			for _, to := range m.toList {
-				_, existed := set[to]
-				if existed {
-					panic(fmt.Sprintf(
-						"duplicated destination field %q for source field %q",
-						dest.GetFullFieldName(to),
-						source.GetFullFieldName(m.from),
-					))
-				}
-				set[to] = emptyStruct{}
+		if len(m.toList) == 1 && m.when == nil && !conditional {
+			to := m.toList[0]
+			if _, existed := set[to]; existed {
+				return nil, duplicatedError(index, m.from, to)
 			}
+			set[to] = emptyStruct{}
+		}
+
+		alternative := mappingAlternative[F2]{
+			when: m.when,
 		}
-		fieldMap[m.from] = append(fieldMap[m.from], m.toList)
+		if conditional {
+			expr := m.expr
+			alternative.expr = &expr
+		} else {
+			alternative.results = m.expr.alternatives(context.Background())
+		}
+		fieldMap[m.from] = append(fieldMap[m.from], alternative)
 	}
 
 	return &Mapper[F1, T1, F2, T2]{
-		parentOf: source.ParentOf,
+		parentOf: source.mappingParentOf,
 		fieldMap: fieldMap,
 		mappings: mappingDataList,
+
+		sourceName: source.displayFullName,
+		destName:   dest.displayFullName,
+		destCosts:  dest.getCosts,
+
+		sourceMapKey: source.mapKeyName,
+		destMapKey:   dest.mapKeyName,
+	}, nil
+}
+
+// evaluate returns the results of the alternative for ctx, only the first one if firstOnly is true.
+// Returns false if the alternative is not matched.
+func (a mappingAlternative[F2]) evaluate(ctx context.Context, firstOnly bool) ([][]F2, bool) {
+	if a.when != nil && !a.when(ctx) {
+		return nil, false
 	}
+
+	results := a.results
+	if a.expr != nil {
+		results = a.expr.alternatives(ctx)
+	}
+	if len(results) == 0 {
+		return nil, false
+	}
+	if firstOnly {
+		return results[:1], true
+	}
+	return results, true
 }
-func (m *Mapper[F1, T1, F2, T2]) findMappedFieldsForSourceField(
-	sourceField F1, resultSet map[F2]emptyStruct, result []F2,
-) []F2 {
+
+// findAlternatives returns the results of the matched alternatives of the source field,
+// or of its nearest ancestor with any matched alternatives
+func (m *Mapper[F1, T1, F2, T2]) findAlternatives(
+	ctx context.Context, sourceField F1, firstOnly bool,
+) [][]F2 {
 	var empty F1
 
 	for {
-		for _, destFields := range m.fieldMap[sourceField] {
-			for _, f := range destFields {
-				_, existed := resultSet[f]
-				if existed {
-					continue
-				}
-				resultSet[f] = emptyStruct{}
-				result = append(result, f)
+		var result [][]F2
+		for _, alternative := range m.fieldMap[sourceField] {
+			destFieldsList, ok := alternative.evaluate(ctx, firstOnly)
+			if !ok {
+				continue
 			}
-			if len(destFields) > 0 {
+			result = append(result, destFieldsList...)
+			if firstOnly {
 				return result
 			}
 		}
+		if len(result) > 0 {
+			return result
+		}
 
 		sourceField = m.parentOf(sourceField)
 		if sourceField == empty {
-			return result
+			return nil
 		}
 	}
 }
 
-// FindMappedFields ...
+// Rules returns the rules of the Mapper in order, with pattern rules already expanded
+func (m *Mapper[F1, T1, F2, T2]) Rules() []MappingData[F1, F2] {
+	return append([]MappingData[F1, F2](nil), m.mappings...)
+}
+
+// FindMappedFields is FindMappedFieldsCtx with context.Background()
 func (m *Mapper[F1, T1, F2, T2]) FindMappedFields(sourceFields []F1) []F2 {
+	return m.FindMappedFieldsCtx(context.Background(), sourceFields)
+}
+
+// FindMappedFieldsCtx finds the destination fields of the source fields, using only rules without conditions
+// or with conditions that are true for ctx. A source field without such rules uses the rules of its parent.
+//
+// When a source field has several such rules or AnyOf branches (alternatives), the first one is used if no destination field
+// has a cost. Otherwise, the alternatives are chosen to minimize the total cost of all destination fields,
+// each field is counted once even if it is used by several source fields, see FieldMap.SetCost.
+func (m *Mapper[F1, T1, F2, T2]) FindMappedFieldsCtx(ctx context.Context, sourceFields []F1) []F2 {
 	var result []F2
 	resultSet := map[F2]emptyStruct{}
 
-	for _, sourceField := range sourceFields {
-		result = m.findMappedFieldsForSourceField(sourceField, resultSet, result)
+	appendFields := func(fields []F2) {
+		for _, f := range fields {
+			_, existed := resultSet[f]
+			if existed {
+				continue
+			}
+			resultSet[f] = emptyStruct{}
+			result = append(result, f)
+		}
 	}
 
+	costs := m.destCosts()
+	if len(costs) == 0 {
+		for _, sourceField := range sourceFields {
+			for _, destFields := range m.findAlternatives(ctx, sourceField, true) {
+				appendFields(destFields)
+			}
+		}
+		return result
+	}
+
+	candidates := make([][][]F2, 0, len(sourceFields))
+	for _, sourceField := range sourceFields {
+		candidates = append(candidates, m.findAlternatives(ctx, sourceField, false))
+	}
+	for _, destFields := range selectAlternatives(candidates, costs) {
+		appendFields(destFields)
+	}
 	return result
 }
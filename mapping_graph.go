@@ -0,0 +1,309 @@
+package fieldmap
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mappingGraphRule is a rule of the Mapper with its position among the rules of the same source field
+type mappingGraphRule[F1, F2 Field] struct {
+	index int
+	data  MappingData[F1, F2]
+
+	// alternative is 1-based, zero when the source field has only one rule
+	alternative  int
+	alternatives int
+}
+
+func (m *Mapper[F1, T1, F2, T2]) graphRules() []mappingGraphRule[F1, F2] {
+	counts := map[F1]int{}
+	for _, data := range m.mappings {
+		counts[data.from]++
+	}
+
+	seen := map[F1]int{}
+	rules := make([]mappingGraphRule[F1, F2], 0, len(m.mappings))
+	for i, data := range m.mappings {
+		rule := mappingGraphRule[F1, F2]{
+			index: i + 1,
+			data:  data,
+		}
+		if counts[data.from] > 1 {
+			seen[data.from]++
+			rule.alternative = seen[data.from]
+			rule.alternatives = counts[data.from]
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (m *Mapper[F1, T1, F2, T2]) graphFields() ([]F1, []F2) {
+	sourceSet := map[F1]emptyStruct{}
+	destSet := map[F2]emptyStruct{}
+
+	var sources []F1
+	var dests []F2
+	for _, data := range m.mappings {
+		if _, ok := sourceSet[data.from]; !ok {
+			sourceSet[data.from] = emptyStruct{}
+			sources = append(sources, data.from)
+		}
+		for _, to := range data.toList {
+			if _, ok := destSet[to]; !ok {
+				destSet[to] = emptyStruct{}
+				dests = append(dests, to)
+			}
+		}
+	}
+
+	sort.Slice(sources, func(i, j int) bool { return sources[i] < sources[j] })
+	sort.Slice(dests, func(i, j int) bool { return dests[i] < dests[j] })
+	return sources, dests
+}
+
+// alternativeLabel also marks rules with conditions
+func (r mappingGraphRule[F1, F2]) alternativeLabel() string {
+	var label string
+	if r.alternative > 0 {
+		label = "OR " + strconv.Itoa(r.alternative) + "/" + strconv.Itoa(r.alternatives)
+	}
+	if r.data.Conditional() {
+		if len(label) > 0 {
+			label += ", "
+		}
+		label += "when"
+	}
+	return label
+}
+
+// exprGraphEdge is an edge from a junction node of an AllOf / AnyOf group to a sub expression
+type exprGraphEdge struct {
+	from string
+	to   string
+
+	toJunction  bool
+	conditional bool
+}
+
+// walkExprGraph calls junction for each AllOf / AnyOf group of the expression, labeled "AND" / "OR",
+// and edge for each edge from a group to its sub expressions. The id of the group of the whole expression is prefix,
+// nested groups are numbered after it, e.g. "r2_1", "r2_2".
+func walkExprGraph[F Field](
+	e Expr[F], prefix string, id string, next *int,
+	junction func(id string, label string), edge func(e exprGraphEdge),
+) {
+	label := "AND"
+	if e.kind == exprAnyOf {
+		label = "OR"
+	}
+	junction(id, label)
+
+	for _, child := range e.children {
+		if child.kind == exprDest {
+			edge(exprGraphEdge{
+				from:        id,
+				to:          "d" + strconv.FormatInt(int64(child.field), 10),
+				conditional: child.when != nil,
+			})
+			continue
+		}
+
+		*next++
+		childID := prefix + "_" + strconv.Itoa(*next)
+		edge(exprGraphEdge{
+			from:        id,
+			to:          childID,
+			toJunction:  true,
+			conditional: child.when != nil,
+		})
+		walkExprGraph(child, prefix, childID, next, junction, edge)
+	}
+}
+
+// WriteDOT writes the rules in the Graphviz DOT language.
+// The destination fields of an AllOf or AnyOf expression are drawn through a junction node labeled "AND" or "OR",
+// nested groups are drawn through nested junction nodes,
+// the rules of the same source field (OR alternatives) are labeled by their order,
+// rules and sub expressions with conditions are labeled "when", and rules coming from WithInheritMapping are dashed.
+func (m *Mapper[F1, T1, F2, T2]) WriteDOT(w io.Writer) error {
+	ew := &errWriter{w: w}
+	sources, dests := m.graphFields()
+
+	ew.printf("digraph mapper {\n")
+	ew.printf("  rankdir=LR;\n")
+	ew.printf("  node [shape=box];\n")
+
+	ew.printf("  subgraph cluster_source {\n")
+	ew.printf("    label=\"source\";\n")
+	for _, field := range sources {
+		ew.printf("    s%d [label=\"%s\"];\n", int64(field), escapeGraphLabel(m.sourceName(field)))
+	}
+	ew.printf("  }\n")
+
+	ew.printf("  subgraph cluster_dest {\n")
+	ew.printf("    label=\"dest\";\n")
+	for _, field := range dests {
+		ew.printf("    d%d [label=\"%s\"];\n", int64(field), escapeGraphLabel(m.destName(field)))
+	}
+	ew.printf("  }\n")
+
+	for _, rule := range m.graphRules() {
+		var attrs []string
+		if label := rule.alternativeLabel(); len(label) > 0 {
+			attrs = append(attrs, "label=\""+label+"\"")
+		}
+		if rule.data.inherited {
+			attrs = append(attrs, "style=dashed")
+		}
+
+		if rule.data.expr.kind == exprDest {
+			ew.printf("  s%d -> d%d%s;\n", int64(rule.data.from), int64(rule.data.expr.field), dotAttrs(attrs))
+			continue
+		}
+
+		id := "r" + strconv.Itoa(rule.index)
+		next := 0
+		walkExprGraph(rule.data.expr, id, id, &next,
+			func(junctionID string, label string) {
+				ew.printf("  %s [shape=circle, label=\"%s\"];\n", junctionID, label)
+				if junctionID == id {
+					ew.printf("  s%d -> %s%s;\n", int64(rule.data.from), id, dotAttrs(append(attrs, "arrowhead=none")))
+				}
+			},
+			func(e exprGraphEdge) {
+				var edgeAttrs []string
+				if e.conditional {
+					edgeAttrs = append(edgeAttrs, "label=\"when\"")
+				}
+				if rule.data.inherited {
+					edgeAttrs = append(edgeAttrs, "style=dashed")
+				}
+				if e.toJunction {
+					edgeAttrs = append(edgeAttrs, "arrowhead=none")
+				}
+				ew.printf("  %s -> %s%s;\n", e.from, e.to, dotAttrs(edgeAttrs))
+			},
+		)
+	}
+
+	ew.printf("}\n")
+	return ew.err
+}
+
+func dotAttrs(attrs []string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(attrs, ", ") + "]"
+}
+
+func escapeMermaidLabel(label string) string {
+	return strings.ReplaceAll(label, `"`, "#quot;")
+}
+
+// WriteMermaid writes the rules as a Mermaid flowchart, with the same conventions as WriteDOT
+func (m *Mapper[F1, T1, F2, T2]) WriteMermaid(w io.Writer) error {
+	ew := &errWriter{w: w}
+	sources, dests := m.graphFields()
+
+	ew.printf("graph LR\n")
+
+	ew.printf("  subgraph source\n")
+	for _, field := range sources {
+		ew.printf("    s%d[\"%s\"]\n", int64(field), escapeMermaidLabel(m.sourceName(field)))
+	}
+	ew.printf("  end\n")
+
+	ew.printf("  subgraph dest\n")
+	for _, field := range dests {
+		ew.printf("    d%d[\"%s\"]\n", int64(field), escapeMermaidLabel(m.destName(field)))
+	}
+	ew.printf("  end\n")
+
+	for _, rule := range m.graphRules() {
+		arrow := "-->"
+		line := "---"
+		if rule.data.inherited {
+			arrow = "-.->"
+			line = "-.-"
+		}
+		if label := rule.alternativeLabel(); len(label) > 0 {
+			arrow += "|" + label + "|"
+			line += "|" + label + "|"
+		}
+
+		if rule.data.expr.kind == exprDest {
+			ew.printf("  s%d %s d%d\n", int64(rule.data.from), arrow, int64(rule.data.expr.field))
+			continue
+		}
+
+		id := "r" + strconv.Itoa(rule.index)
+		next := 0
+		walkExprGraph(rule.data.expr, id, id, &next,
+			func(junctionID string, label string) {
+				ew.printf("  %s((%s))\n", junctionID, label)
+				if junctionID == id {
+					ew.printf("  s%d %s %s\n", int64(rule.data.from), line, id)
+				}
+			},
+			func(e exprGraphEdge) {
+				edgeArrow := "-->"
+				if e.toJunction {
+					edgeArrow = "---"
+				}
+				if rule.data.inherited {
+					edgeArrow = "-.->"
+					if e.toJunction {
+						edgeArrow = "-.-"
+					}
+				}
+				if e.conditional {
+					edgeArrow += "|when|"
+				}
+				ew.printf("  %s %s %s\n", e.from, edgeArrow, e.to)
+			},
+		)
+	}
+
+	return ew.err
+}
+
+func escapeMarkdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// WriteMarkdownTable writes the rules as a Markdown table, one row per rule in the order of the rules.
+// Destinations are written as expressions with AND / OR operators, see Expr.
+func (m *Mapper[F1, T1, F2, T2]) WriteMarkdownTable(w io.Writer) error {
+	ew := &errWriter{w: w}
+
+	ew.printf("| # | Source | Destinations | Alternative | Inherited |\n")
+	ew.printf("|---|--------|--------------|-------------|-----------|\n")
+
+	destName := func(field F2) string {
+		return escapeMarkdownCell(m.destName(field))
+	}
+
+	for _, rule := range m.graphRules() {
+		var destinations strings.Builder
+		rule.data.expr.format(&destinations, destName, false)
+
+		inherited := ""
+		if rule.data.inherited {
+			inherited = "yes"
+		}
+
+		ew.printf("| %d | %s | %s | %s | %s |\n",
+			rule.index,
+			escapeMarkdownCell(m.sourceName(rule.data.from)),
+			destinations.String(),
+			rule.alternativeLabel(),
+			inherited,
+		)
+	}
+
+	return ew.err
+}
@@ -0,0 +1,288 @@
+package fieldmap
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func newMapperForGraph() *Mapper[sourceField, sourceDataComplex, destField, destDataComplex] {
+	sourceFm := New[sourceField, sourceDataComplex]()
+	destFm := New[destField, destDataComplex]()
+
+	source := sourceFm.GetMapping()
+	dest := destFm.GetMapping()
+
+	subSourceFm := New[sourceField, sourceSeller]()
+	subDestFm := New[destField, destDetail]()
+
+	subSource := subSourceFm.GetMapping()
+	subDest := subDestFm.GetMapping()
+
+	subMapper := NewMapper(
+		subSourceFm, subDestFm,
+		WithSimpleMapping(subSourceFm, subDestFm,
+			NewMapping(subSource.ID, subDest.Body),
+			NewMapping(subSource.Info.Logo, subDest.Root, subDest.Body),
+		),
+	)
+
+	return NewMapper(
+		sourceFm, destFm,
+		WithSimpleMapping(sourceFm, destFm,
+			NewMapping(source.Sku, dest.Info.Sku),
+			NewMapping(source.Name, dest.Info.Name, dest.SearchText),
+			NewMapping(source.Name, dest.Detail.Body),
+		),
+		WithInheritMapping(
+			sourceFm, destFm,
+			subMapper,
+			sourceDataComplex.GetSeller,
+			destDataComplex.GetDetail,
+		),
+	)
+}
+
+func TestMapper_WriteDOT(t *testing.T) {
+	m := newMapperForGraph()
+
+	var buf strings.Builder
+	err := m.WriteDOT(&buf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `digraph mapper {
+  rankdir=LR;
+  node [shape=box];
+  subgraph cluster_source {
+    label="source";
+    s2 [label="Sku"];
+    s3 [label="Name"];
+    s6 [label="Seller.ID"];
+    s9 [label="Seller.Info.Logo"];
+  }
+  subgraph cluster_dest {
+    label="dest";
+    d3 [label="Info.Sku"];
+    d4 [label="Info.Name"];
+    d5 [label="Detail"];
+    d6 [label="Detail.Body"];
+    d7 [label="SearchText"];
+  }
+  s2 -> d3;
+  r2 [shape=circle, label="AND"];
+  s3 -> r2 [label="OR 1/2", arrowhead=none];
+  r2 -> d4;
+  r2 -> d7;
+  s3 -> d6 [label="OR 2/2"];
+  s6 -> d6 [style=dashed];
+  r5 [shape=circle, label="AND"];
+  s9 -> r5 [style=dashed, arrowhead=none];
+  r5 -> d5 [style=dashed];
+  r5 -> d6 [style=dashed];
+}
+`, buf.String())
+
+	assert.Equal(t, errors.New("write error"), m.WriteDOT(errorWriter{}))
+}
+
+func TestMapper_WriteMermaid(t *testing.T) {
+	m := newMapperForGraph()
+
+	var buf strings.Builder
+	err := m.WriteMermaid(&buf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `graph LR
+  subgraph source
+    s2["Sku"]
+    s3["Name"]
+    s6["Seller.ID"]
+    s9["Seller.Info.Logo"]
+  end
+  subgraph dest
+    d3["Info.Sku"]
+    d4["Info.Name"]
+    d5["Detail"]
+    d6["Detail.Body"]
+    d7["SearchText"]
+  end
+  s2 --> d3
+  r2((AND))
+  s3 ---|OR 1/2| r2
+  r2 --> d4
+  r2 --> d7
+  s3 -->|OR 2/2| d6
+  s6 -.-> d6
+  r5((AND))
+  s9 -.- r5
+  r5 -.-> d5
+  r5 -.-> d6
+`, buf.String())
+
+	assert.Equal(t, errors.New("write error"), m.WriteMermaid(errorWriter{}))
+}
+
+func newMapperForNestedGraph() *Mapper[sourceField, sourceDataComplex, destField, destDataComplex] {
+	sourceFm := New[sourceField, sourceDataComplex]()
+	destFm := New[destField, destDataComplex]()
+
+	source := sourceFm.GetMapping()
+	dest := destFm.GetMapping()
+
+	always := func(ctx context.Context) bool { return true }
+
+	return NewMapper(
+		sourceFm, destFm,
+		WithSimpleMapping(sourceFm, destFm,
+			NewMappingExpr(source.Sku, AnyOf(
+				Dest(dest.Info.Sku),
+				AllOf(Dest(dest.SearchText), Dest(dest.Detail.Body)),
+			)),
+			NewMappingExpr(source.Name, AllOf(
+				Dest(dest.Info.Name),
+				AnyOf(Dest(dest.SearchText).When(always), Dest(dest.Detail.Body)),
+			)),
+		),
+	)
+}
+
+func TestMapper_WriteDOT_NestedExpr(t *testing.T) {
+	m := newMapperForNestedGraph()
+
+	var buf strings.Builder
+	err := m.WriteDOT(&buf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `digraph mapper {
+  rankdir=LR;
+  node [shape=box];
+  subgraph cluster_source {
+    label="source";
+    s2 [label="Sku"];
+    s3 [label="Name"];
+  }
+  subgraph cluster_dest {
+    label="dest";
+    d3 [label="Info.Sku"];
+    d4 [label="Info.Name"];
+    d6 [label="Detail.Body"];
+    d7 [label="SearchText"];
+  }
+  r1 [shape=circle, label="OR"];
+  s2 -> r1 [arrowhead=none];
+  r1 -> d3;
+  r1 -> r1_1 [arrowhead=none];
+  r1_1 [shape=circle, label="AND"];
+  r1_1 -> d7;
+  r1_1 -> d6;
+  r2 [shape=circle, label="AND"];
+  s3 -> r2 [label="when", arrowhead=none];
+  r2 -> d4;
+  r2 -> r2_1 [arrowhead=none];
+  r2_1 [shape=circle, label="OR"];
+  r2_1 -> d7 [label="when"];
+  r2_1 -> d6;
+}
+`, buf.String())
+}
+
+func TestMapper_WriteMermaid_NestedExpr(t *testing.T) {
+	m := newMapperForNestedGraph()
+
+	var buf strings.Builder
+	err := m.WriteMermaid(&buf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `graph LR
+  subgraph source
+    s2["Sku"]
+    s3["Name"]
+  end
+  subgraph dest
+    d3["Info.Sku"]
+    d4["Info.Name"]
+    d6["Detail.Body"]
+    d7["SearchText"]
+  end
+  r1((OR))
+  s2 --- r1
+  r1 --> d3
+  r1 --- r1_1
+  r1_1((AND))
+  r1_1 --> d7
+  r1_1 --> d6
+  r2((AND))
+  s3 ---|when| r2
+  r2 --> d4
+  r2 --- r2_1
+  r2_1((OR))
+  r2_1 -->|when| d7
+  r2_1 --> d6
+`, buf.String())
+}
+
+func TestMapper_WriteMarkdownTable(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		m := newMapperForGraph()
+
+		var buf strings.Builder
+		err := m.WriteMarkdownTable(&buf)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `| # | Source | Destinations | Alternative | Inherited |
+|---|--------|--------------|-------------|-----------|
+| 1 | Sku | Info.Sku |  |  |
+| 2 | Name | Info.Name AND SearchText | OR 1/2 |  |
+| 3 | Name | Detail.Body | OR 2/2 |  |
+| 4 | Seller.ID | Detail.Body |  | yes |
+| 5 | Seller.Info.Logo | Detail AND Detail.Body |  | yes |
+`, buf.String())
+
+		assert.Equal(t, errors.New("write error"), m.WriteMarkdownTable(errorWriter{}))
+	})
+
+	t.Run("conditional", func(t *testing.T) {
+		sourceFm := New[sourceField, sourceDataSimple]()
+		destFm := New[destField, destDataSimple]()
+
+		source := sourceFm.GetMapping()
+		dest := destFm.GetMapping()
+		enabled := func(ctx context.Context) bool { return true }
+
+		m := NewMapper(
+			sourceFm, destFm,
+			WithSimpleMapping(sourceFm, destFm,
+				NewMapping(source.Sku, dest.Info).When(enabled),
+				NewMapping(source.Name, dest.Info).When(enabled),
+				NewMapping(source.Name, dest.Detail),
+			),
+		)
+
+		var buf strings.Builder
+		err := m.WriteMarkdownTable(&buf)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `| # | Source | Destinations | Alternative | Inherited |
+|---|--------|--------------|-------------|-----------|
+| 1 | Sku | Info | when |  |
+| 2 | Name | Info | OR 1/2, when |  |
+| 3 | Name | Detail | OR 2/2 |  |
+`, buf.String())
+	})
+
+	t.Run("root field", func(t *testing.T) {
+		sourceFm := New[sourceField, sourceDataSimple]()
+		destFm := New[destField, destDataSimple]()
+
+		m := NewMapper(
+			sourceFm, destFm,
+			WithSimpleMapping(sourceFm, destFm,
+				NewMapping(sourceFm.GetMapping().Root, destFm.GetMapping().Detail),
+			),
+		)
+
+		var buf strings.Builder
+		err := m.WriteMarkdownTable(&buf)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, `| # | Source | Destinations | Alternative | Inherited |
+|---|--------|--------------|-------------|-----------|
+| 1 | <root> | Detail |  |  |
+`, buf.String())
+	})
+}
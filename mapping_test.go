@@ -1,6 +1,7 @@
 package fieldmap
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -404,3 +405,134 @@ func TestMapping_Complex(t *testing.T) {
 		assert.Equal(t, 0, len(m.FindMappedFields([]sourceField{source.Seller.Info.Root})))
 	})
 }
+
+type tenantKey struct{}
+
+func withTenant(tenant string) context.Context {
+	return context.WithValue(context.Background(), tenantKey{}, tenant)
+}
+
+func isTenant(tenant string) func(ctx context.Context) bool {
+	return func(ctx context.Context) bool {
+		return ctx.Value(tenantKey{}) == tenant
+	}
+}
+
+func TestMapping_Conditional(t *testing.T) {
+	sourceFm := New[sourceField, sourceDataComplex]()
+	destFm := New[destField, destDataComplex]()
+
+	source := sourceFm.GetMapping()
+	dest := destFm.GetMapping()
+
+	t.Run("first match among passed rules", func(t *testing.T) {
+		m := NewMapper(
+			sourceFm, destFm,
+			WithSimpleMapping(sourceFm, destFm,
+				NewMapping(source.Name, dest.SearchText).When(isTenant("a")),
+				NewMapping(source.Name, dest.Info.Name).When(isTenant("b")),
+				NewMapping(source.Name, dest.Detail.Body),
+			),
+		)
+
+		assert.Equal(t, []destField{dest.SearchText}, m.FindMappedFieldsCtx(withTenant("a"), []sourceField{source.Name}))
+		assert.Equal(t, []destField{dest.Info.Name}, m.FindMappedFieldsCtx(withTenant("b"), []sourceField{source.Name}))
+		assert.Equal(t, []destField{dest.Detail.Body}, m.FindMappedFieldsCtx(withTenant("c"), []sourceField{source.Name}))
+		assert.Equal(t, []destField{dest.Detail.Body}, m.FindMappedFields([]sourceField{source.Name}))
+
+		rules := m.Rules()
+		assert.Equal(t, true, rules[0].Conditional())
+		assert.Equal(t, false, rules[2].Conditional())
+	})
+
+	t.Run("fallback to parent when no rule passes", func(t *testing.T) {
+		m := NewMapper(
+			sourceFm, destFm,
+			WithSimpleMapping(sourceFm, destFm,
+				NewMapping(source.Seller.Root, dest.Detail.Root),
+				NewMapping(source.Seller.Name, dest.Detail.Body).When(isTenant("a")),
+			),
+		)
+
+		assert.Equal(t, []destField{dest.Detail.Body}, m.FindMappedFieldsCtx(withTenant("a"), []sourceField{source.Seller.Name}))
+		assert.Equal(t, []destField{dest.Detail.Root}, m.FindMappedFieldsCtx(withTenant("b"), []sourceField{source.Seller.Name}))
+	})
+
+	t.Run("same destination with different conditions", func(t *testing.T) {
+		m := NewMapper(
+			sourceFm, destFm,
+			WithSimpleMapping(sourceFm, destFm,
+				NewMapping(source.Sku, dest.Info.Sku).When(isTenant("a")),
+				NewMapping(source.Sku, dest.Info.Sku).When(isTenant("b")),
+			),
+		)
+
+		assert.Equal(t, []destField{dest.Info.Sku}, m.FindMappedFieldsCtx(withTenant("b"), []sourceField{source.Sku}))
+		assert.Equal(t, 0, len(m.FindMappedFieldsCtx(withTenant("c"), []sourceField{source.Sku})))
+	})
+
+	t.Run("inherited", func(t *testing.T) {
+		subSourceFm := New[sourceField, sourceSeller]()
+		subDestFm := New[destField, destDetail]()
+
+		subMapper := NewMapper(
+			subSourceFm, subDestFm,
+			WithSimpleMapping(subSourceFm, subDestFm,
+				NewMapping(subSourceFm.GetMapping().ID, subDestFm.GetMapping().Body).When(isTenant("a")),
+			),
+		)
+
+		m := NewMapper(
+			sourceFm, destFm,
+			WithInheritMapping(sourceFm, destFm, subMapper,
+				sourceDataComplex.GetSeller,
+				destDataComplex.GetDetail,
+			),
+		)
+
+		assert.Equal(t, []destField{dest.Detail.Body}, m.FindMappedFieldsCtx(withTenant("a"), []sourceField{source.Seller.ID}))
+		assert.Equal(t, 0, len(m.FindMappedFieldsCtx(withTenant("b"), []sourceField{source.Seller.ID})))
+	})
+
+	t.Run("typed condition value", func(t *testing.T) {
+		type tenantInfo struct {
+			name string
+		}
+		type flags struct {
+			detail bool
+		}
+
+		m := NewMapper(
+			sourceFm, destFm,
+			WithSimpleMapping(sourceFm, destFm,
+				NewMapping(source.Name, dest.SearchText).When(ConditionOn(func(tenant tenantInfo) bool {
+					return tenant.name == "a"
+				})),
+				NewMapping(source.Name, dest.Detail.Body).When(ConditionOn(func(f flags) bool {
+					return f.detail
+				})),
+				NewMapping(source.Name, dest.Info.Name),
+			),
+		)
+
+		ctx := WithConditionValue(context.Background(), tenantInfo{name: "a"})
+		assert.Equal(t, []destField{dest.SearchText}, m.FindMappedFieldsCtx(ctx, []sourceField{source.Name}))
+
+		ctx = WithConditionValue(context.Background(), tenantInfo{name: "b"})
+		assert.Equal(t, []destField{dest.Info.Name}, m.FindMappedFieldsCtx(ctx, []sourceField{source.Name}))
+
+		ctx = WithConditionValue(ctx, flags{detail: true})
+		assert.Equal(t, []destField{dest.Detail.Body}, m.FindMappedFieldsCtx(ctx, []sourceField{source.Name}))
+
+		assert.Equal(t, []destField{dest.Info.Name}, m.FindMappedFields([]sourceField{source.Name}))
+	})
+
+	t.Run("nil condition", func(t *testing.T) {
+		assert.PanicsWithValue(t, "nil condition of mapping", func() {
+			NewMapping(source.Sku, dest.Info.Sku).When(nil)
+		})
+		assert.PanicsWithValue(t, "nil condition of mapping", func() {
+			ConditionOn[string](nil)
+		})
+	})
+}
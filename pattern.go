@@ -0,0 +1,160 @@
+package fieldmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pattern is the source of a pattern mapping, one of the forms:
+//
+//	Seller.*                    direct children of Seller
+//	Seller.**                   all descendants of Seller
+//	Seller.** except Seller.Logo, Seller.Attr
+//
+// Paths are full field names, a pattern without path, such as "**", starts from the root.
+// Excluded fields are excluded together with their descendants.
+type Pattern struct {
+	text string
+
+	base        string
+	descendants bool
+	excludes    []string
+}
+
+// String returns the text of the pattern
+func (p *Pattern) String() string {
+	return p.text
+}
+
+func parsePattern(text string) (*Pattern, error) {
+	p := &Pattern{text: text}
+
+	expr := strings.TrimSpace(text)
+	if index := strings.Index(expr, " except "); index >= 0 {
+		for _, exclude := range strings.Split(expr[index+len(" except "):], ",") {
+			exclude = strings.TrimSpace(exclude)
+			if len(exclude) == 0 {
+				return nil, fmt.Errorf("empty exclusion in pattern %q", text)
+			}
+			p.excludes = append(p.excludes, exclude)
+		}
+		expr = strings.TrimSpace(expr[:index])
+	}
+
+	var wildcard string
+	if index := strings.LastIndex(expr, "."); index >= 0 {
+		p.base = expr[:index]
+		wildcard = expr[index+1:]
+	} else {
+		wildcard = expr
+	}
+
+	switch wildcard {
+	case "*":
+	case "**":
+		p.descendants = true
+	default:
+		return nil, fmt.Errorf("invalid pattern %q", text)
+	}
+	if strings.Contains(p.base, "*") {
+		return nil, fmt.Errorf("invalid pattern %q", text)
+	}
+	return p, nil
+}
+
+// PatternMappingData is a mapping rule with a pattern source, expanded by WithPatternMapping
+type PatternMappingData[F Field] struct {
+	pattern *Pattern
+	toList  []F
+}
+
+// NewPatternMapping creates a rule mapping every source field matched by the pattern to the destination fields.
+// Panics if the syntax of the pattern is invalid, see Pattern.
+func NewPatternMapping[F2 Field](pattern string, toList ...F2) PatternMappingData[F2] {
+	if len(toList) == 0 {
+		panic("missing destination fields")
+	}
+	p, err := parsePattern(pattern)
+	if err != nil {
+		panic(err.Error())
+	}
+	return PatternMappingData[F2]{pattern: p, toList: toList}
+}
+
+// WithPatternMapping expands pattern rules into ordinary rules, in the order of field ordinals.
+// Keys registered by RegisterKey are not expanded, they are matched through the Any field of their map node.
+// Panics if a path of a pattern is not found or an excluded field is not matched by its pattern.
+func WithPatternMapping[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]](
+	source *FieldMap[F1, T1], _ *FieldMap[F2, T2],
+	patterns ...PatternMappingData[F2],
+) MappingOption[F1, T1, F2, T2] {
+	return func(mappings []MappingData[F1, F2]) []MappingData[F1, F2] {
+		for _, p := range patterns {
+			for _, from := range source.expandPattern(p.pattern) {
+				m := NewMapping(from, p.toList...)
+				m.pattern = p.pattern
+				mappings = append(mappings, m)
+			}
+		}
+		return mappings
+	}
+}
+
+func (f *FieldMap[F, T]) findPatternPath(p *Pattern, path string) F {
+	if len(path) == 0 {
+		return f.structRoot
+	}
+	field, ok := f.FindByFullFieldName(path)
+	if !ok {
+		panic(fmt.Sprintf("field %q of pattern %q not found", path, p.text))
+	}
+	return field
+}
+
+func (f *FieldMap[F, T]) expandPattern(p *Pattern) []F {
+	base := f.findPatternPath(p, p.base)
+
+	excluded := map[F]emptyStruct{}
+	for _, path := range p.excludes {
+		field := f.findPatternPath(p, path)
+		if !f.matchPattern(p, base, field) {
+			panic(fmt.Sprintf("excluded field %q is not matched by pattern %q", path, p.text))
+		}
+		excluded[field] = emptyStruct{}
+	}
+
+	var result []F
+	var visit func(field F)
+	visit = func(field F) {
+		for _, child := range f.ChildrenOf(field) {
+			if f.IsMapKey(child) {
+				continue
+			}
+			if _, ok := excluded[child]; ok {
+				continue
+			}
+			result = append(result, child)
+			if p.descendants {
+				visit(child)
+			}
+		}
+	}
+	visit(base)
+
+	return result
+}
+
+func (f *FieldMap[F, T]) matchPattern(p *Pattern, base F, field F) bool {
+	if field == base {
+		return false
+	}
+	if !p.descendants {
+		return f.ParentOf(field) == base
+	}
+	for _, ancestor := range f.AncestorOf(field) {
+		if ancestor == base {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package fieldmap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	t.Run("direct children", func(t *testing.T) {
+		p, err := parsePattern("Seller.Info.*")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &Pattern{text: "Seller.Info.*", base: "Seller.Info"}, p)
+		assert.Equal(t, "Seller.Info.*", p.String())
+	})
+
+	t.Run("descendants with exclusions", func(t *testing.T) {
+		p, err := parsePattern("Seller.** except Seller.ID,Seller.Info ")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &Pattern{
+			text:        "Seller.** except Seller.ID,Seller.Info ",
+			base:        "Seller",
+			descendants: true,
+			excludes:    []string{"Seller.ID", "Seller.Info"},
+		}, p)
+	})
+
+	t.Run("root", func(t *testing.T) {
+		p, err := parsePattern("**")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &Pattern{text: "**", descendants: true}, p)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parsePattern("Seller")
+		assert.Equal(t, `invalid pattern "Seller"`, err.Error())
+
+		_, err = parsePattern("Seller.***")
+		assert.Equal(t, `invalid pattern "Seller.***"`, err.Error())
+
+		_, err = parsePattern("*.Name")
+		assert.Equal(t, `invalid pattern "*.Name"`, err.Error())
+
+		_, err = parsePattern("Seller.* except Seller.ID,")
+		assert.Equal(t, `empty exclusion in pattern "Seller.* except Seller.ID,"`, err.Error())
+	})
+}
+
+func TestMapping_Pattern(t *testing.T) {
+	sourceFm := New[sourceField, sourceDataComplex]()
+	destFm := New[destField, destDataComplex]()
+
+	source := sourceFm.GetMapping()
+	dest := destFm.GetMapping()
+
+	t.Run("direct children", func(t *testing.T) {
+		m := NewMapper(
+			sourceFm, destFm,
+			WithPatternMapping(sourceFm, destFm,
+				NewPatternMapping("Seller.*", dest.Detail.Body),
+			),
+		)
+
+		rules := m.Rules()
+		assert.Equal(t, 3, len(rules))
+		assert.Equal(t, source.Seller.ID, rules[0].From())
+		assert.Equal(t, source.Seller.Name, rules[1].From())
+		assert.Equal(t, source.Seller.Info.Root, rules[2].From())
+		for _, rule := range rules {
+			assert.Equal(t, []destField{dest.Detail.Body}, rule.ToList())
+			assert.Equal(t, "Seller.*", rule.Pattern().String())
+			assert.Equal(t, false, rule.Inherited())
+		}
+
+		assert.Equal(t, []destField{dest.Detail.Body}, m.FindMappedFields([]sourceField{source.Seller.Info.Logo}))
+		assert.Equal(t, 0, len(m.FindMappedFields([]sourceField{source.Seller.Root})))
+	})
+
+	t.Run("descendants with exclusions", func(t *testing.T) {
+		m := NewMapper(
+			sourceFm, destFm,
+			WithPatternMapping(sourceFm, destFm,
+				NewPatternMapping("Seller.** except Seller.Info.Logo", dest.Detail.Body),
+			),
+			WithSimpleMapping(sourceFm, destFm,
+				NewMapping(source.Seller.Info.Logo, dest.Info.Name),
+			),
+		)
+
+		var fromList []sourceField
+		for _, rule := range m.Rules() {
+			fromList = append(fromList, rule.From())
+		}
+		assert.Equal(t, []sourceField{
+			source.Seller.ID, source.Seller.Name, source.Seller.Info.Root,
+			source.Seller.Info.Logo,
+		}, fromList)
+		assert.Equal(t, (*Pattern)(nil), m.Rules()[3].Pattern())
+
+		assert.Equal(t, []destField{dest.Detail.Body}, m.FindMappedFields([]sourceField{source.Seller.Info.Root}))
+		assert.Equal(t, []destField{dest.Info.Name}, m.FindMappedFields([]sourceField{source.Seller.Info.Logo}))
+	})
+
+	t.Run("exclude subtree from root", func(t *testing.T) {
+		m := NewMapper(
+			sourceFm, destFm,
+			WithPatternMapping(sourceFm, destFm,
+				NewPatternMapping("** except Seller, ImageURL", dest.SearchText),
+			),
+		)
+		assert.Equal(t, 3, len(m.Rules()))
+		assert.Equal(t, []destField{dest.SearchText}, m.FindMappedFields([]sourceField{source.Body}))
+		assert.Equal(t, 0, len(m.FindMappedFields([]sourceField{source.Seller.Info.Logo, source.ImageURL})))
+	})
+
+	t.Run("inherited rules keep patterns", func(t *testing.T) {
+		subSourceFm := New[sourceField, sourceSeller]()
+		subDestFm := New[destField, destDetail]()
+
+		subMapper := NewMapper(
+			subSourceFm, subDestFm,
+			WithPatternMapping(subSourceFm, subDestFm,
+				NewPatternMapping("Info.*", subDestFm.GetMapping().Body),
+			),
+		)
+
+		m := NewMapper(
+			sourceFm, destFm,
+			WithInheritMapping(sourceFm, destFm, subMapper,
+				sourceDataComplex.GetSeller,
+				destDataComplex.GetDetail,
+			),
+		)
+
+		rules := m.Rules()
+		assert.Equal(t, 1, len(rules))
+		assert.Equal(t, source.Seller.Info.Logo, rules[0].From())
+		assert.Equal(t, []destField{dest.Detail.Body}, rules[0].ToList())
+		assert.Equal(t, true, rules[0].Inherited())
+		assert.Equal(t, "Info.*", rules[0].Pattern().String())
+	})
+
+	t.Run("duplicated with simple mapping", func(t *testing.T) {
+		assert.PanicsWithValue(t, `duplicated destination field "Detail.Body" for source field "Seller.Name"`, func() {
+			NewMapper(
+				sourceFm, destFm,
+				WithSimpleMapping(sourceFm, destFm,
+					NewMapping(source.Seller.Name, dest.Detail.Body),
+				),
+				WithPatternMapping(sourceFm, destFm,
+					NewPatternMapping("Seller.*", dest.Detail.Body),
+				),
+			)
+		})
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		assert.PanicsWithValue(t, "missing destination fields", func() {
+			NewPatternMapping[destField]("Seller.*")
+		})
+		assert.PanicsWithValue(t, `invalid pattern "Seller"`, func() {
+			NewPatternMapping("Seller", dest.Info.Name)
+		})
+		assert.PanicsWithValue(t, `field "Unknown" of pattern "Unknown.*" not found`, func() {
+			NewMapper(sourceFm, destFm, WithPatternMapping(sourceFm, destFm,
+				NewPatternMapping("Unknown.*", dest.Info.Name),
+			))
+		})
+		assert.PanicsWithValue(t, `excluded field "Seller.Info.Logo" is not matched by pattern "Seller.* except Seller.Info.Logo"`, func() {
+			NewMapper(sourceFm, destFm, WithPatternMapping(sourceFm, destFm,
+				NewPatternMapping("Seller.* except Seller.Info.Logo", dest.Info.Name),
+			))
+		})
+	})
+}
+
+func TestMapping_Pattern_MapNode(t *testing.T) {
+	sourceFm := New[sourceField, sourceCatalog]()
+	destFm := New[destField, destDataComplex]()
+
+	source := sourceFm.GetMapping()
+	dest := destFm.GetMapping()
+
+	color := sourceFm.RegisterKey(source.Attributes, "color")
+
+	m := NewMapper(
+		sourceFm, destFm,
+		WithPatternMapping(sourceFm, destFm,
+			NewPatternMapping("Attributes.*", dest.SearchText),
+		),
+	)
+
+	rules := m.Rules()
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, source.Attributes.Any, rules[0].From())
+
+	assert.Equal(t, []destField{dest.SearchText}, m.FindMappedFields([]sourceField{color}))
+}
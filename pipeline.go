@@ -0,0 +1,300 @@
+package fieldmap
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Pipeline is a DAG of FieldMaps (stages) connected by Mappers (edges), for finding the fields
+// of all downstream stages affected by changed fields of a stage.
+// Stages and edges are added by AddPipelineStage and AddPipelineEdge, then the pipeline is validated by Build.
+type Pipeline struct {
+	stages     map[string]*pipelineStage
+	stageNames []string
+
+	built bool
+	order []string
+}
+
+type pipelineStage struct {
+	name      string
+	fieldMap  any
+	fieldType reflect.Type
+
+	edges []pipelineEdge
+}
+
+type pipelineEdge struct {
+	to   string
+	find func(ctx context.Context, fields []int64) []int64
+}
+
+// PipelineResult contains the affected fields of stages, see StageFields
+type PipelineResult struct {
+	pipeline *Pipeline
+
+	stages []string
+	fields map[string][]int64
+}
+
+// NewPipeline creates an empty Pipeline
+func NewPipeline() *Pipeline {
+	return &Pipeline{
+		stages: map[string]*pipelineStage{},
+	}
+}
+
+func (p *Pipeline) mustNotBuilt() {
+	if p.built {
+		panic("pipeline is already built")
+	}
+}
+
+func (p *Pipeline) mustGetStage(name string) *pipelineStage {
+	stage, ok := p.stages[name]
+	if !ok {
+		panic(fmt.Sprintf("stage %q not found", name))
+	}
+	return stage
+}
+
+// AddPipelineStage adds a FieldMap as a stage of the pipeline, panics if the name is empty or already used
+func AddPipelineStage[F Field, T MapType[F]](p *Pipeline, name string, fieldMap *FieldMap[F, T]) {
+	p.mustNotBuilt()
+	if len(name) == 0 {
+		panic("empty stage name")
+	}
+	if _, existed := p.stages[name]; existed {
+		panic(fmt.Sprintf("duplicated stage %q", name))
+	}
+
+	p.stages[name] = &pipelineStage{
+		name:      name,
+		fieldMap:  fieldMap,
+		fieldType: reflect.TypeOf(F(0)),
+	}
+	p.stageNames = append(p.stageNames, name)
+}
+
+// AddPipelineEdge adds a Mapper from the FieldMap of the stage from to the FieldMap of the stage to.
+// Panics if the stages are not found, or the types of their FieldMaps are not the types of the Mapper.
+func AddPipelineEdge[F1 Field, T1 MapType[F1], F2 Field, T2 MapType[F2]](
+	p *Pipeline, from string, to string, mapper *Mapper[F1, T1, F2, T2],
+) {
+	p.mustNotBuilt()
+
+	fromStage := p.mustGetStage(from)
+	toStage := p.mustGetStage(to)
+
+	if _, ok := fromStage.fieldMap.(*FieldMap[F1, T1]); !ok {
+		panic(fmt.Sprintf("invalid source type of mapper for stage %q", from))
+	}
+	if _, ok := toStage.fieldMap.(*FieldMap[F2, T2]); !ok {
+		panic(fmt.Sprintf("invalid destination type of mapper for stage %q", to))
+	}
+	for _, e := range fromStage.edges {
+		if e.to == to {
+			panic(fmt.Sprintf("duplicated edge from %q to %q", from, to))
+		}
+	}
+
+	fromStage.edges = append(fromStage.edges, pipelineEdge{
+		to: to,
+		find: func(ctx context.Context, fields []int64) []int64 {
+			sourceFields := make([]F1, 0, len(fields))
+			for _, field := range fields {
+				sourceFields = append(sourceFields, F1(field))
+			}
+
+			destFields := mapper.FindMappedFieldsCtx(ctx, sourceFields)
+
+			result := make([]int64, 0, len(destFields))
+			for _, field := range destFields {
+				result = append(result, int64(field))
+			}
+			return result
+		},
+	})
+}
+
+// Build validates the pipeline and computes the topological order of stages,
+// ties are broken by the order of AddPipelineStage. Panics if the edges form a cycle.
+// No stage or edge can be added after Build.
+func (p *Pipeline) Build() {
+	p.mustNotBuilt()
+
+	inDegree := map[string]int{}
+	for _, name := range p.stageNames {
+		for _, e := range p.stages[name].edges {
+			inDegree[e.to]++
+		}
+	}
+
+	order := make([]string, 0, len(p.stageNames))
+	done := map[string]bool{}
+	for len(order) < len(p.stageNames) {
+		next, found := "", false
+		for _, name := range p.stageNames {
+			if !done[name] && inDegree[name] == 0 {
+				next, found = name, true
+				break
+			}
+		}
+		if !found {
+			panic(fmt.Sprintf("cycle in pipeline: %s", strings.Join(p.findCycle(done), " -> ")))
+		}
+
+		done[next] = true
+		order = append(order, next)
+		for _, e := range p.stages[next].edges {
+			inDegree[e.to]--
+		}
+	}
+
+	p.order = order
+	p.built = true
+}
+
+// findCycle returns a cycle among stages that are not done, the first stage is repeated at the end
+func (p *Pipeline) findCycle(done map[string]bool) []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, e := range p.stages[name].edges {
+			if done[e.to] {
+				continue
+			}
+			switch state[e.to] {
+			case visiting:
+				for i, n := range path {
+					if n == e.to {
+						return append(append([]string(nil), path[i:]...), e.to)
+					}
+				}
+			case 0:
+				if cycle := visit(e.to); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range p.stageNames {
+		if done[name] || state[name] != 0 {
+			continue
+		}
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// Stages returns the names of stages in topological order, panics if the pipeline is not built
+func (p *Pipeline) Stages() []string {
+	p.mustBuilt()
+	return append([]string(nil), p.order...)
+}
+
+func (p *Pipeline) mustBuilt() {
+	if !p.built {
+		panic("pipeline is not built")
+	}
+}
+
+// mustFieldTypeOf panics if F is not the field type of the FieldMap of the stage
+func mustFieldTypeOf[F Field](stage *pipelineStage) {
+	fieldType := reflect.TypeOf(F(0))
+	if fieldType != stage.fieldType {
+		panic(fmt.Sprintf("invalid field type %s for stage %q", fieldType, stage.name))
+	}
+}
+
+// PropagateChanges finds the affected fields of all stages downstream of the stage with the changed fields,
+// visiting stages in topological order. The affected fields of a stage are the union of the results
+// of the Mappers of its incoming edges. The result also contains the changed fields of the stage itself.
+// Panics if the pipeline is not built, the stage is not found or F is not the field type of the stage.
+func PropagateChanges[F Field](
+	ctx context.Context, p *Pipeline, stage string, changed []F,
+) *PipelineResult {
+	p.mustBuilt()
+	mustFieldTypeOf[F](p.mustGetStage(stage))
+
+	fields := make([]int64, 0, len(changed))
+	for _, field := range changed {
+		fields = append(fields, int64(field))
+	}
+
+	result := &PipelineResult{
+		pipeline: p,
+		fields:   map[string][]int64{},
+	}
+	sets := map[string]map[int64]emptyStruct{}
+
+	addFields := func(name string, fields []int64) {
+		set, ok := sets[name]
+		if !ok {
+			set = map[int64]emptyStruct{}
+			sets[name] = set
+			result.fields[name] = []int64{}
+		}
+		for _, field := range fields {
+			if _, existed := set[field]; existed {
+				continue
+			}
+			set[field] = emptyStruct{}
+			result.fields[name] = append(result.fields[name], field)
+		}
+	}
+	addFields(stage, fields)
+
+	for _, name := range p.order {
+		stageFields, ok := result.fields[name]
+		if !ok {
+			continue
+		}
+		result.stages = append(result.stages, name)
+
+		for _, e := range p.stages[name].edges {
+			addFields(e.to, e.find(ctx, stageFields))
+		}
+	}
+
+	return result
+}
+
+// Stages returns the names of the changed stage and its downstream stages, in topological order
+func (r *PipelineResult) Stages() []string {
+	return r.stages
+}
+
+// StageFields returns the affected fields of a stage, nil if the stage is not downstream of the changed stage.
+// Panics if the stage is not found or F is not the field type of the stage.
+func StageFields[F Field](r *PipelineResult, stage string) []F {
+	mustFieldTypeOf[F](r.pipeline.mustGetStage(stage))
+
+	fields, ok := r.fields[stage]
+	if !ok {
+		return nil
+	}
+	result := make([]F, 0, len(fields))
+	for _, field := range fields {
+		result = append(result, F(field))
+	}
+	return result
+}
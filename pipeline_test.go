@@ -0,0 +1,189 @@
+package fieldmap
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type pipelineTest struct {
+	apiFm    *FieldMap[sourceField, sourceDataSimple]
+	domainFm *FieldMap[sourceField, sourceDataComplex]
+	searchFm *FieldMap[destField, destDataComplex]
+	cacheFm  *FieldMap[destField, destDataSimple]
+
+	apiToDomain   *Mapper[sourceField, sourceDataSimple, sourceField, sourceDataComplex]
+	domainToCache *Mapper[sourceField, sourceDataComplex, destField, destDataSimple]
+}
+
+func newPipelineTest() *pipelineTest {
+	p := &pipelineTest{
+		apiFm:    New[sourceField, sourceDataSimple](),
+		domainFm: New[sourceField, sourceDataComplex](),
+		searchFm: New[destField, destDataComplex](),
+		cacheFm:  New[destField, destDataSimple](),
+	}
+
+	api := p.apiFm.GetMapping()
+	domain := p.domainFm.GetMapping()
+	cache := p.cacheFm.GetMapping()
+
+	p.apiToDomain = NewMapper(p.apiFm, p.domainFm,
+		WithSimpleMapping(p.apiFm, p.domainFm,
+			NewMapping(api.Sku, domain.Sku),
+			NewMapping(api.Name, domain.Name),
+			NewMapping(api.Body, domain.Body),
+		),
+	)
+	p.domainToCache = NewMapper(p.domainFm, p.cacheFm,
+		WithSimpleMapping(p.domainFm, p.cacheFm,
+			NewMapping(domain.Sku, cache.Info),
+			NewMapping(domain.Body, cache.Detail),
+		),
+	)
+	return p
+}
+
+func (p *pipelineTest) newPipeline() *Pipeline {
+	domain := p.domainFm.GetMapping()
+	search := p.searchFm.GetMapping()
+	cache := p.cacheFm.GetMapping()
+
+	domainToSearch := NewMapper(p.domainFm, p.searchFm,
+		WithSimpleMapping(p.domainFm, p.searchFm,
+			NewMapping(domain.Sku, search.Info.Sku),
+			NewMapping(domain.Name, search.Info.Name, search.SearchText),
+			NewMapping(domain.Body, search.Detail.Body),
+		),
+	)
+	searchToCache := NewMapper(p.searchFm, p.cacheFm,
+		WithSimpleMapping(p.searchFm, p.cacheFm,
+			NewMapping(search.Detail.Root, cache.Detail),
+			NewMapping(search.SearchText, cache.Info),
+		),
+	)
+
+	pipeline := NewPipeline()
+	AddPipelineStage(pipeline, "cache", p.cacheFm)
+	AddPipelineStage(pipeline, "search", p.searchFm)
+	AddPipelineStage(pipeline, "domain", p.domainFm)
+	AddPipelineStage(pipeline, "api", p.apiFm)
+
+	AddPipelineEdge(pipeline, "api", "domain", p.apiToDomain)
+	AddPipelineEdge(pipeline, "domain", "search", domainToSearch)
+	AddPipelineEdge(pipeline, "domain", "cache", p.domainToCache)
+	AddPipelineEdge(pipeline, "search", "cache", searchToCache)
+	return pipeline
+}
+
+func TestPipeline(t *testing.T) {
+	p := newPipelineTest()
+
+	api := p.apiFm.GetMapping()
+	domain := p.domainFm.GetMapping()
+	search := p.searchFm.GetMapping()
+	cache := p.cacheFm.GetMapping()
+
+	t.Run("propagate changes", func(t *testing.T) {
+		pipeline := p.newPipeline()
+		pipeline.Build()
+		assert.Equal(t, []string{"api", "domain", "search", "cache"}, pipeline.Stages())
+
+		result := PropagateChanges(context.Background(), pipeline, "api", []sourceField{api.Name})
+		assert.Equal(t, []string{"api", "domain", "search", "cache"}, result.Stages())
+		assert.Equal(t, []sourceField{api.Name}, StageFields[sourceField](result, "api"))
+		assert.Equal(t, []sourceField{domain.Name}, StageFields[sourceField](result, "domain"))
+		assert.Equal(t, []destField{search.Info.Name, search.SearchText}, StageFields[destField](result, "search"))
+		assert.Equal(t, []destField{cache.Info}, StageFields[destField](result, "cache"))
+
+		result = PropagateChanges(context.Background(), pipeline, "domain", []sourceField{domain.Body, domain.Sku})
+		assert.Equal(t, []string{"domain", "search", "cache"}, result.Stages())
+		assert.Equal(t, []sourceField(nil), StageFields[sourceField](result, "api"))
+		assert.Equal(t, []destField{search.Detail.Body, search.Info.Sku}, StageFields[destField](result, "search"))
+		assert.Equal(t, []destField{cache.Detail, cache.Info}, StageFields[destField](result, "cache"))
+	})
+
+	t.Run("nothing affected", func(t *testing.T) {
+		pipeline := p.newPipeline()
+		pipeline.Build()
+
+		result := PropagateChanges(context.Background(), pipeline, "search", []destField{search.Info.Sku})
+		assert.Equal(t, []string{"search", "cache"}, result.Stages())
+		assert.Equal(t, []destField{}, StageFields[destField](result, "cache"))
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		pipeline := p.newPipeline()
+		domainToAPI := NewMapper(p.domainFm, p.apiFm,
+			WithSimpleMapping(p.domainFm, p.apiFm,
+				NewMapping(domain.Sku, api.Sku),
+			),
+		)
+		AddPipelineEdge(pipeline, "domain", "api", domainToAPI)
+
+		assert.PanicsWithValue(t, "cycle in pipeline: domain -> api -> domain", func() {
+			pipeline.Build()
+		})
+	})
+
+	t.Run("self loop", func(t *testing.T) {
+		pipeline := NewPipeline()
+		AddPipelineStage(pipeline, "domain", p.domainFm)
+		AddPipelineEdge(pipeline, "domain", "domain", NewMapper(p.domainFm, p.domainFm))
+
+		assert.PanicsWithValue(t, "cycle in pipeline: domain -> domain", func() {
+			pipeline.Build()
+		})
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		pipeline := NewPipeline()
+		AddPipelineStage(pipeline, "api", p.apiFm)
+		AddPipelineStage(pipeline, "domain", p.domainFm)
+		AddPipelineStage(pipeline, "cache", p.cacheFm)
+
+		assert.PanicsWithValue(t, `duplicated stage "api"`, func() {
+			AddPipelineStage(pipeline, "api", p.apiFm)
+		})
+		assert.PanicsWithValue(t, `empty stage name`, func() {
+			AddPipelineStage(pipeline, "", p.apiFm)
+		})
+		assert.PanicsWithValue(t, `stage "search" not found`, func() {
+			AddPipelineEdge(pipeline, "domain", "search", p.domainToCache)
+		})
+		assert.PanicsWithValue(t, `invalid source type of mapper for stage "api"`, func() {
+			AddPipelineEdge(pipeline, "api", "cache", p.domainToCache)
+		})
+		assert.PanicsWithValue(t, `invalid destination type of mapper for stage "api"`, func() {
+			AddPipelineEdge(pipeline, "api", "api", p.apiToDomain)
+		})
+
+		AddPipelineEdge(pipeline, "api", "domain", p.apiToDomain)
+		assert.PanicsWithValue(t, `duplicated edge from "api" to "domain"`, func() {
+			AddPipelineEdge(pipeline, "api", "domain", p.apiToDomain)
+		})
+
+		assert.PanicsWithValue(t, "pipeline is not built", func() {
+			PropagateChanges(context.Background(), pipeline, "api", []sourceField{api.Sku})
+		})
+
+		pipeline.Build()
+		assert.PanicsWithValue(t, "pipeline is already built", func() {
+			AddPipelineStage(pipeline, "search", p.searchFm)
+		})
+		assert.PanicsWithValue(t, `stage "search" not found`, func() {
+			PropagateChanges(context.Background(), pipeline, "search", []destField{search.Info.Sku})
+		})
+		assert.PanicsWithValue(t, `invalid field type fieldmap.destField for stage "api"`, func() {
+			PropagateChanges(context.Background(), pipeline, "api", []destField{1})
+		})
+
+		result := PropagateChanges(context.Background(), pipeline, "api", []sourceField{api.Sku})
+		assert.PanicsWithValue(t, `invalid field type fieldmap.sourceField for stage "cache"`, func() {
+			StageFields[sourceField](result, "cache")
+		})
+		assert.PanicsWithValue(t, `stage "search" not found`, func() {
+			StageFields[destField](result, "search")
+		})
+	})
+}
@@ -0,0 +1,357 @@
+package fieldmap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema returns the description of the field tree, can be stored and loaded with LoadSchema.
+// Keys registered at runtime with RegisterKey are not included.
+func (f *FieldMap[F, T]) Schema() SchemaNode {
+	return f.schemaOf(f.structRoot)
+}
+
+func (f *FieldMap[F, T]) schemaOf(field F) SchemaNode {
+	node := SchemaNode{
+		Name:       f.GetFieldName(field),
+		Attributes: f.GetAttributes(field).Names(),
+		ID:         f.GetStableID(field),
+	}
+
+	if field != f.structRoot && len(f.options.structTags) > 0 {
+		node.Tags = map[string]string{}
+		for _, tag := range f.options.structTags {
+			node.Tags[tag] = f.GetStructTag(tag, field)
+		}
+	}
+
+	if _, ok := f.mapNodes[field]; ok {
+		node.Map = true
+		return node
+	}
+
+	for _, child := range f.ChildrenOf(field) {
+		node.Children = append(node.Children, f.schemaOf(child))
+	}
+	return node
+}
+
+// fingerprintNode is the part of SchemaNode that determines the ordinals of fields
+type fingerprintNode struct {
+	Name     string            `json:"name,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Map      bool              `json:"map,omitempty"`
+	Children []fingerprintNode `json:"children,omitempty"`
+}
+
+func (s SchemaNode) fingerprintNode() fingerprintNode {
+	node := fingerprintNode{
+		Name: s.Name,
+		Tags: s.Tags,
+		Map:  s.Map,
+	}
+	for _, child := range s.Children {
+		node.Children = append(node.Children, child.fingerprintNode())
+	}
+	return node
+}
+
+// Fingerprint returns a stable hash of the field names, struct tags and structure of the schema.
+// Attributes and stable ids are not included, they do not change ordinals.
+func (s SchemaNode) Fingerprint() string {
+	data, err := json.Marshal(s.fingerprintNode())
+	if err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Fingerprint returns the fingerprint of the Schema
+func (f *FieldMap[F, T]) Fingerprint() string {
+	f.fingerprintOnce.Do(func() {
+		f.fingerprint = f.Schema().Fingerprint()
+	})
+	return f.fingerprint
+}
+
+// ordinalFingerprint is Fingerprint with the registered keys in ordinal order,
+// since ordinals of keys depend on the order of RegisterKey calls
+func (f *FieldMap[F, T]) ordinalFingerprint() string {
+	if len(f.mapKeyOf) == 0 {
+		return f.Fingerprint()
+	}
+
+	h := sha256.New()
+	h.Write([]byte(f.Fingerprint()))
+	for _, field := range f.fields {
+		if f.IsMapKey(field) {
+			h.Write([]byte("\n" + f.GetFullFieldName(field)))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ChangeKind ...
+type ChangeKind int
+
+const (
+	// ChangeAdded for fields only in the new schema
+	ChangeAdded ChangeKind = iota + 1
+	// ChangeRemoved for fields only in the old schema
+	ChangeRemoved
+	// ChangeMoved for fields having the same name and struct tags but a different parent
+	ChangeMoved
+	// ChangeRenumbered for fields having a different ordinal
+	ChangeRenumbered
+	// ChangeTagsModified for fields having different struct tags
+	ChangeTagsModified
+	// ChangeStableIDModified for fields having different stable ids
+	ChangeStableIDModified
+)
+
+var changeKindNames = map[ChangeKind]string{
+	ChangeAdded:        "added",
+	ChangeRemoved:      "removed",
+	ChangeMoved:        "moved",
+	ChangeRenumbered:   "renumbered",
+	ChangeTagsModified: "tags modified",
+
+	ChangeStableIDModified: "stable id modified",
+}
+
+// String ...
+func (k ChangeKind) String() string {
+	return changeKindNames[k]
+}
+
+// FieldChange is a change of a single field between two schemas
+type FieldChange struct {
+	Kind ChangeKind
+
+	// FullFieldName is the name in the new schema, or in the old schema for removed fields
+	FullFieldName string
+	// OldFullFieldName is only different from FullFieldName for moved fields
+	OldFullFieldName string
+
+	// OldOrdinal is zero for added fields
+	OldOrdinal int64
+	// NewOrdinal is zero for removed fields
+	NewOrdinal int64
+
+	// OldStableID and NewStableID are only set for fields with modified stable ids
+	OldStableID uint32
+	NewStableID uint32
+
+	// Breaking is true if the change breaks encoded field sets or persisted ordinals
+	Breaking bool
+}
+
+// String ...
+func (c FieldChange) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("added %q (%d)", c.FullFieldName, c.NewOrdinal)
+	case ChangeRemoved:
+		return fmt.Sprintf("removed %q (%d)", c.FullFieldName, c.OldOrdinal)
+	case ChangeMoved:
+		return fmt.Sprintf("moved %q (%d) to %q (%d)", c.OldFullFieldName, c.OldOrdinal, c.FullFieldName, c.NewOrdinal)
+	case ChangeStableIDModified:
+		return fmt.Sprintf("%s %q (id %d -> %d)", c.Kind, c.FullFieldName, c.OldStableID, c.NewStableID)
+	default:
+		return fmt.Sprintf("%s %q (%d -> %d)", c.Kind, c.FullFieldName, c.OldOrdinal, c.NewOrdinal)
+	}
+}
+
+// SchemaDiff is the result of Diff
+type SchemaDiff struct {
+	Changes []FieldChange
+}
+
+// IsBreaking checks whether any change is breaking
+func (d SchemaDiff) IsBreaking() bool {
+	for _, c := range d.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns one change per line
+func (d SchemaDiff) String() string {
+	var buf strings.Builder
+	for _, c := range d.Changes {
+		buf.WriteString(c.String())
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+type flatSchemaField struct {
+	ordinal  int64
+	fullName string
+	name     string
+	tagsKey  string
+	stableID uint32
+}
+
+func flattenSchema(root SchemaNode) []flatSchemaField {
+	var result []flatSchemaField
+
+	var visit func(node SchemaNode, fullName string)
+	visit = func(node SchemaNode, fullName string) {
+		result = append(result, flatSchemaField{
+			ordinal:  int64(len(result)) + 1,
+			fullName: fullName,
+			name:     node.Name,
+			tagsKey:  schemaTagsKey(node.Tags),
+			stableID: node.ID,
+		})
+
+		if node.Map {
+			anyNode := SchemaNode{Name: AnyKeyName}
+			for tag := range node.Tags {
+				if anyNode.Tags == nil {
+					anyNode.Tags = map[string]string{}
+				}
+				anyNode.Tags[tag] = AnyKeyName
+			}
+			visit(anyNode, joinFieldName(fullName, AnyKeyName))
+			return
+		}
+
+		for _, child := range node.Children {
+			visit(child, joinFieldName(fullName, child.Name))
+		}
+	}
+	visit(root, "")
+
+	return result
+}
+
+func schemaTagsKey(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+// Diff compares two versions of a schema, fields are matched by their full field names.
+// Useful in tests to prevent accidental renumbering, with the old schema stored in a golden file.
+func Diff(oldSchema SchemaNode, newSchema SchemaNode) SchemaDiff {
+	oldFields := flattenSchema(oldSchema)
+	newFields := flattenSchema(newSchema)
+
+	oldIndex := map[string]flatSchemaField{}
+	for _, field := range oldFields {
+		oldIndex[field.fullName] = field
+	}
+	newIndex := map[string]flatSchemaField{}
+	for _, field := range newFields {
+		newIndex[field.fullName] = field
+	}
+
+	removedByKey := map[string][]flatSchemaField{}
+	for _, field := range oldFields {
+		if _, ok := newIndex[field.fullName]; !ok {
+			key := field.name + field.tagsKey
+			removedByKey[key] = append(removedByKey[key], field)
+		}
+	}
+
+	addedByKey := map[string][]flatSchemaField{}
+	for _, field := range newFields {
+		if _, ok := oldIndex[field.fullName]; !ok {
+			key := field.name + field.tagsKey
+			addedByKey[key] = append(addedByKey[key], field)
+		}
+	}
+
+	isMoved := func(key string) bool {
+		return len(removedByKey[key]) == 1 && len(addedByKey[key]) == 1
+	}
+
+	var changes []FieldChange
+	for _, field := range newFields {
+		oldField, ok := oldIndex[field.fullName]
+		if !ok {
+			key := field.name + field.tagsKey
+			if isMoved(key) {
+				oldField = removedByKey[key][0]
+				changes = append(changes, FieldChange{
+					Kind:             ChangeMoved,
+					FullFieldName:    field.fullName,
+					OldFullFieldName: oldField.fullName,
+					OldOrdinal:       oldField.ordinal,
+					NewOrdinal:       field.ordinal,
+					Breaking:         true,
+				})
+				continue
+			}
+
+			changes = append(changes, FieldChange{
+				Kind:             ChangeAdded,
+				FullFieldName:    field.fullName,
+				OldFullFieldName: field.fullName,
+				NewOrdinal:       field.ordinal,
+			})
+			continue
+		}
+
+		if oldField.ordinal != field.ordinal {
+			changes = append(changes, FieldChange{
+				Kind:             ChangeRenumbered,
+				FullFieldName:    field.fullName,
+				OldFullFieldName: field.fullName,
+				OldOrdinal:       oldField.ordinal,
+				NewOrdinal:       field.ordinal,
+				Breaking:         true,
+			})
+		}
+		if oldField.stableID != field.stableID {
+			changes = append(changes, FieldChange{
+				Kind:             ChangeStableIDModified,
+				FullFieldName:    field.fullName,
+				OldFullFieldName: field.fullName,
+				OldOrdinal:       oldField.ordinal,
+				NewOrdinal:       field.ordinal,
+				OldStableID:      oldField.stableID,
+				NewStableID:      field.stableID,
+				Breaking:         true,
+			})
+		}
+		if oldField.tagsKey != field.tagsKey {
+			changes = append(changes, FieldChange{
+				Kind:             ChangeTagsModified,
+				FullFieldName:    field.fullName,
+				OldFullFieldName: field.fullName,
+				OldOrdinal:       oldField.ordinal,
+				NewOrdinal:       field.ordinal,
+				Breaking:         true,
+			})
+		}
+	}
+
+	for _, field := range oldFields {
+		if _, ok := newIndex[field.fullName]; ok || isMoved(field.name+field.tagsKey) {
+			continue
+		}
+		changes = append(changes, FieldChange{
+			Kind:             ChangeRemoved,
+			FullFieldName:    field.fullName,
+			OldFullFieldName: field.fullName,
+			OldOrdinal:       field.ordinal,
+			Breaking:         true,
+		})
+	}
+
+	return SchemaDiff{Changes: changes}
+}
@@ -0,0 +1,145 @@
+package fieldmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type productDataV2 struct {
+	Root     field
+	Sku      field      `json:"sku"`
+	Barcode  field      `json:"barcode"`
+	Name     field      `json:"name"`
+	Seller   sellerData `json:"seller"`
+	ImageURL field      `json:"imageUrl"`
+}
+
+func (d productDataV2) GetRoot() field { return d.Root }
+
+type sellerDataV3 struct {
+	Root field
+
+	ID   field      `json:"id"`
+	Name field      `json:"name"`
+	Attr sellerAttr `json:"attr"`
+}
+
+type productDataV3 struct {
+	Root     field
+	Sku      field        `json:"sku"`
+	Name     field        `json:"name"`
+	Seller   sellerDataV3 `json:"seller"`
+	ImageURL field        `json:"imageURL"`
+	Logo     field        `json:"logo"`
+}
+
+func (d productDataV3) GetRoot() field { return d.Root }
+
+type productDataDeprecated struct {
+	Root     field
+	Sku      field      `json:"sku" fieldmap:"id=3"`
+	Name     field      `json:"name" fieldmap:"deprecated"`
+	Seller   sellerData `json:"seller"`
+	ImageURL field      `json:"imageUrl"`
+}
+
+func (d productDataDeprecated) GetRoot() field { return d.Root }
+
+func TestFieldMap_Schema(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		fm := New[field, catalogData](WithStructTags("json"))
+		fm.RegisterKey(fm.GetMapping().Attributes, "color")
+
+		var buf bytes.Buffer
+		err := json.NewEncoder(&buf).Encode(fm.Schema())
+		assert.Equal(t, nil, err)
+
+		schema, err := LoadSchema(&buf)
+		assert.Equal(t, nil, err)
+
+		dynamicFm, err := NewDynamic[field](schema, WithStructTags("json"))
+		assert.Equal(t, nil, err)
+
+		p := fm.GetMapping()
+		assert.Equal(t, p.Attributes.Any, dynamicFm.MapNode("Attributes").Any)
+		assert.Equal(t, p.Name, dynamicFm.Field("Name"))
+
+		assert.Equal(t, fm.Fingerprint(), dynamicFm.Fingerprint())
+	})
+
+	t.Run("fingerprint", func(t *testing.T) {
+		fm := New[field, productData](WithStructTags("json"))
+
+		assert.Equal(t, 64, len(fm.Fingerprint()))
+		assert.Equal(t, fm.Fingerprint(), New[field, productData](WithStructTags("json")).Fingerprint())
+
+		assert.NotEqual(t, fm.Fingerprint(), New[field, productData]().Fingerprint())
+		assert.NotEqual(t, fm.Fingerprint(), New[field, productDataV2](WithStructTags("json")).Fingerprint())
+
+		// attributes and stable ids do not change ordinals
+		assert.Equal(t, fm.Fingerprint(), New[field, productDataDeprecated](WithStructTags("json")).Fingerprint())
+	})
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("same", func(t *testing.T) {
+		fm := New[field, productData](WithStructTags("json"))
+
+		diff := Diff(fm.Schema(), fm.Schema())
+		assert.Equal(t, 0, len(diff.Changes))
+		assert.Equal(t, false, diff.IsBreaking())
+	})
+
+	t.Run("added at the end", func(t *testing.T) {
+		oldSchema := SchemaNode{
+			Children: []SchemaNode{{Name: "Sku"}, {Name: "Name"}},
+		}
+		newSchema := SchemaNode{
+			Children: []SchemaNode{{Name: "Sku"}, {Name: "Name"}, {Name: "Attributes", Map: true}},
+		}
+
+		diff := Diff(oldSchema, newSchema)
+		assert.Equal(t, false, diff.IsBreaking())
+		assert.Equal(t, "added \"Attributes\" (4)\nadded \"Attributes.*\" (5)\n", diff.String())
+	})
+
+	t.Run("inserted in the middle", func(t *testing.T) {
+		oldFm := New[field, productData](WithStructTags("json"))
+		newFm := New[field, productDataV2](WithStructTags("json"))
+
+		diff := Diff(oldFm.Schema(), newFm.Schema())
+		assert.Equal(t, true, diff.IsBreaking())
+		assert.Equal(t, FieldChange{
+			Kind:             ChangeAdded,
+			FullFieldName:    "Barcode",
+			OldFullFieldName: "Barcode",
+			NewOrdinal:       3,
+		}, diff.Changes[0])
+		assert.Equal(t, FieldChange{
+			Kind:             ChangeRenumbered,
+			FullFieldName:    "Name",
+			OldFullFieldName: "Name",
+			OldOrdinal:       3,
+			NewOrdinal:       4,
+			Breaking:         true,
+		}, diff.Changes[1])
+		assert.Equal(t, 10, len(diff.Changes))
+	})
+
+	t.Run("moved removed and tags modified", func(t *testing.T) {
+		oldFm := New[field, productData](WithStructTags("json"))
+		newFm := New[field, productDataV3](WithStructTags("json"))
+
+		diff := Diff(oldFm.Schema(), newFm.Schema())
+		assert.Equal(t, true, diff.IsBreaking())
+		assert.Equal(t, `renumbered "Seller.Attr" (8 -> 7)
+renumbered "Seller.Attr.Code" (9 -> 8)
+renumbered "Seller.Attr.Name" (10 -> 9)
+renumbered "ImageURL" (11 -> 10)
+tags modified "ImageURL" (11 -> 10)
+moved "Seller.Logo" (7) to "Logo" (11)
+`, diff.String())
+	})
+}
@@ -0,0 +1,84 @@
+package fieldmap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func stableIDKey(ids []uint32) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(parts, ".")
+}
+
+// setStableID is called right after the field is appended, zero id means no stable id
+func (f *FieldMap[F, T]) setStableID(field F, id uint32) error {
+	fullFieldName := f.GetFullFieldName(field)
+	if id == 0 {
+		if f.options.stableIDs {
+			return fmt.Errorf("missing stable id for field %q", fullFieldName)
+		}
+		return nil
+	}
+
+	parent := f.ParentOf(field)
+	for _, sibling := range f.children[f.indexOf(parent)] {
+		if sibling != field && f.stableIDs[f.indexOf(sibling)] == id {
+			return fmt.Errorf(
+				"duplicated stable id %d for fields %q and %q",
+				id, f.GetFullFieldName(sibling), fullFieldName,
+			)
+		}
+	}
+	f.stableIDs[f.indexOf(field)] = id
+
+	parentPath, ok := f.StableIDOf(parent)
+	if !ok {
+		return nil
+	}
+
+	path := make([]uint32, 0, len(parentPath)+1)
+	path = append(path, parentPath...)
+	path = append(path, id)
+
+	f.stableIDPaths[field] = path
+	f.stableIDIndex[stableIDKey(path)] = field
+	return nil
+}
+
+func (f *FieldMap[F, T]) mustSetStableID(field F, id uint32) {
+	if err := f.setStableID(field, id); err != nil {
+		panic(err.Error())
+	}
+}
+
+// GetStableID returns the stable id of the field, unique within its parent, zero if not declared
+func (f *FieldMap[F, T]) GetStableID(field F) uint32 {
+	return f.stableIDs[f.indexOf(field)]
+}
+
+// StableIDOf returns the stable ids of the field and its ancestors, from the top-level field down to the field.
+// Returns false if the field or any of its ancestors does not have a stable id.
+func (f *FieldMap[F, T]) StableIDOf(field F) ([]uint32, bool) {
+	if field == f.structRoot {
+		return []uint32{}, true
+	}
+	path, ok := f.stableIDPaths[field]
+	if !ok {
+		return nil, false
+	}
+	return append([]uint32(nil), path...), true
+}
+
+// ByStableID returns the field with the stable ids from the top-level field down to the field.
+// Without any ids, returns the root.
+func (f *FieldMap[F, T]) ByStableID(ids ...uint32) (F, bool) {
+	if len(ids) == 0 {
+		return f.structRoot, true
+	}
+	field, ok := f.stableIDIndex[stableIDKey(ids)]
+	return field, ok
+}
@@ -0,0 +1,167 @@
+package fieldmap
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type sellerWithIDs struct {
+	Root field
+
+	ID   field `fieldmap:"id=1"`
+	Name field `fieldmap:"id=2"`
+}
+
+type productWithIDs struct {
+	Root field
+
+	Name   field          `fieldmap:"id=2"`
+	Sku    field          `fieldmap:"id=1"`
+	Seller sellerWithIDs  `fieldmap:"id=3"`
+	Attrs  MapNode[field] `fieldmap:"id=4"`
+}
+
+func (d productWithIDs) GetRoot() field { return d.Root }
+
+type productWithMissingID struct {
+	Root field
+
+	Sku  field `fieldmap:"id=1"`
+	Name field
+}
+
+func (d productWithMissingID) GetRoot() field { return d.Root }
+
+type productWithDuplicatedID struct {
+	Root field
+
+	Sku  field `fieldmap:"id=1"`
+	Name field `fieldmap:"id=1"`
+}
+
+func (d productWithDuplicatedID) GetRoot() field { return d.Root }
+
+type sellerWithDuplicatedIDs struct {
+	Root field
+
+	ID   field `fieldmap:"id=1"`
+	Name field `fieldmap:"id=1"`
+}
+
+type productWithDuplicatedNestedID struct {
+	Root   field
+	Seller sellerWithDuplicatedIDs
+}
+
+func (d productWithDuplicatedNestedID) GetRoot() field { return d.Root }
+
+type productWithInvalidID struct {
+	Root field
+	Sku  field `fieldmap:"id=0"`
+}
+
+func (d productWithInvalidID) GetRoot() field { return d.Root }
+
+func TestFieldMap__StableID(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		fm := New[field, productWithIDs](WithStableIDs())
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, uint32(2), fm.GetStableID(p.Name))
+		assert.Equal(t, uint32(0), fm.GetStableID(p.Root))
+
+		ids, ok := fm.StableIDOf(p.Seller.Name)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, []uint32{3, 2}, ids)
+
+		ids[0] = 100
+		ids, _ = fm.StableIDOf(p.Seller.Name)
+		assert.Equal(t, []uint32{3, 2}, ids)
+
+		ids, ok = fm.StableIDOf(p.Root)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, []uint32{}, ids)
+
+		_, ok = fm.StableIDOf(p.Attrs.Any)
+		assert.Equal(t, false, ok)
+
+		f, ok := fm.ByStableID(3, 1)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, p.Seller.ID, f)
+
+		f, ok = fm.ByStableID(4)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, p.Attrs.Root, f)
+
+		f, ok = fm.ByStableID()
+		assert.Equal(t, true, ok)
+		assert.Equal(t, p.Root, f)
+
+		_, ok = fm.ByStableID(3, 3)
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("not required without option", func(t *testing.T) {
+		fm := New[field, productWithMissingID]()
+
+		p := fm.GetMapping()
+
+		f, ok := fm.ByStableID(1)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, p.Sku, f)
+
+		_, ok = fm.StableIDOf(p.Name)
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("panics when missing", func(t *testing.T) {
+		assert.PanicsWithValue(t, `missing stable id for field "Name"`, func() {
+			New[field, productWithMissingID](WithStableIDs())
+		})
+	})
+
+	t.Run("panics when duplicated", func(t *testing.T) {
+		assert.PanicsWithValue(t, `duplicated stable id 1 for fields "Sku" and "Name"`, func() {
+			New[field, productWithDuplicatedID]()
+		})
+	})
+
+	t.Run("panics when duplicated under parent without stable id", func(t *testing.T) {
+		assert.PanicsWithValue(t, `duplicated stable id 1 for fields "Seller.ID" and "Seller.Name"`, func() {
+			New[field, productWithDuplicatedNestedID]()
+		})
+	})
+
+	t.Run("panics when invalid", func(t *testing.T) {
+		assert.PanicsWithValue(t, `invalid fieldmap tag for field "Sku": invalid stable id "0"`, func() {
+			New[field, productWithInvalidID]()
+		})
+	})
+
+	t.Run("dynamic", func(t *testing.T) {
+		fm, err := NewDynamic[field](New[field, productWithIDs]().Schema(), WithStableIDs())
+		assert.Equal(t, nil, err)
+
+		f, ok := fm.ByStableID(3, 2)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, fm.Field("Seller.Name"), f)
+
+		_, err = NewDynamic[field](SchemaNode{
+			Children: []SchemaNode{{Name: "Sku", ID: 1}, {Name: "Name", ID: 1}},
+		})
+		assert.Equal(t, errors.New(`duplicated stable id 1 for fields "Sku" and "Name"`), err)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		oldSchema := SchemaNode{Children: []SchemaNode{{Name: "Sku", ID: 1}}}
+		newSchema := SchemaNode{Children: []SchemaNode{{Name: "Sku", ID: 2}}}
+
+		diff := Diff(oldSchema, newSchema)
+		assert.Equal(t, true, diff.IsBreaking())
+		assert.Equal(t, "stable id modified \"Sku\" (id 1 -> 2)\n", diff.String())
+		assert.Equal(t, uint32(1), diff.Changes[0].OldStableID)
+		assert.Equal(t, uint32(2), diff.Changes[0].NewStableID)
+	})
+}
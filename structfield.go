@@ -0,0 +1,131 @@
+package fieldmap
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type structFieldInfo struct {
+	value reflect.Value
+	field reflect.StructField
+	depth int
+
+	options tagOptions
+}
+
+func isPointerToStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+}
+
+func isEmbeddedStruct(fieldType reflect.StructField) bool {
+	if !fieldType.Anonymous {
+		return false
+	}
+	t := fieldType.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	_, isMapNode := reflect.New(t).Interface().(interface{ isMapNode() })
+	return !isMapNode
+}
+
+func allocatePointer(val reflect.Value) reflect.Value {
+	if val.IsNil() {
+		val.Set(reflect.New(val.Type().Elem()))
+	}
+	return val.Elem()
+}
+
+// collectStructFields returns the fields of a struct with the fields of embedded structs promoted,
+// following the rules of Go and encoding/json:
+// a field at a shallower depth hides the fields with the same name at deeper depths,
+// and fields with the same name at the same depth are ambiguous.
+func collectStructFields(val reflect.Value, fullFieldName string) []structFieldInfo {
+	all := collectFieldsOfDepth(val, 0, nil, fullFieldName)
+
+	minDepth := map[string]int{}
+	count := map[string]int{}
+	for _, info := range all {
+		name := info.field.Name
+		depth, existed := minDepth[name]
+		if !existed || info.depth < depth {
+			minDepth[name] = info.depth
+			count[name] = 1
+		} else if info.depth == depth {
+			count[name]++
+		}
+	}
+
+	result := make([]structFieldInfo, 0, len(all))
+	for _, info := range all {
+		name := info.field.Name
+		if info.depth != minDepth[name] {
+			continue
+		}
+		if count[name] > 1 {
+			panic(fmt.Sprintf("ambiguous field %q", joinFieldName(fullFieldName, name)))
+		}
+		result = append(result, info)
+	}
+	return result
+}
+
+func collectFieldsOfDepth(
+	val reflect.Value, depth int, embeddedTypes []reflect.Type, fullFieldName string,
+) []structFieldInfo {
+	var result []structFieldInfo
+
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Field(i)
+		fieldType := val.Type().Field(i)
+
+		options := getTagOptions(fieldType, joinFieldName(fullFieldName, fieldType.Name))
+		if options.skip {
+			continue
+		}
+
+		if options.leaf || !isEmbeddedStruct(fieldType) {
+			if !fieldType.IsExported() {
+				continue
+			}
+			result = append(result, structFieldInfo{
+				value: field,
+				field: fieldType,
+				depth: depth,
+
+				options: options,
+			})
+			continue
+		}
+
+		// exported fields of an embedded struct of an unexported type can still be set by reflection,
+		// but an embedded pointer of an unexported type can not be allocated
+		if field.Kind() == reflect.Ptr {
+			if !field.CanSet() {
+				panic(fmt.Sprintf("embedded pointer to unexported struct %q", fieldType.Type.String()))
+			}
+			field = allocatePointer(field)
+		}
+
+		for _, embeddedType := range embeddedTypes {
+			if embeddedType == field.Type() {
+				panic(fmt.Sprintf("recursive embedded struct %q", fieldType.Type.String()))
+			}
+		}
+
+		result = append(result, collectFieldsOfDepth(
+			field, depth+1, append(embeddedTypes, field.Type()), fullFieldName,
+		)...)
+	}
+	return result
+}
+
+func joinFieldName(prefix string, name string) string {
+	if len(prefix) > 0 {
+		return prefix + "." + name
+	}
+	return name
+}
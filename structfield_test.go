@@ -0,0 +1,159 @@
+package fieldmap
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type baseData struct {
+	ID   field `json:"id"`
+	Name field `json:"name"`
+}
+
+type BaseAudit struct {
+	CreatedAt field `json:"createdAt"`
+}
+
+type productWithEmbedded struct {
+	Root field
+	baseData
+	*BaseAudit
+	Sku field `json:"sku"`
+}
+
+func (d productWithEmbedded) GetRoot() field { return d.Root }
+
+type productWithUnexportedPointer struct {
+	Root field
+	*baseData
+}
+
+func (d productWithUnexportedPointer) GetRoot() field { return d.Root }
+
+type productWithPointer struct {
+	Root   field
+	Sku    field       `json:"sku"`
+	Seller *sellerData `json:"seller"`
+	Price  field       `json:"price"`
+}
+
+func (d productWithPointer) GetRoot() field { return d.Root }
+
+type baseWithRoot struct {
+	Root field
+	ID   field
+}
+
+type productWithPromotedRoot struct {
+	baseWithRoot
+	Name field
+}
+
+func (d productWithPromotedRoot) GetRoot() field { return d.Root }
+
+type otherBase struct {
+	ID field
+}
+
+type productWithAmbiguousField struct {
+	Root field
+	baseData
+	otherBase
+}
+
+func (d productWithAmbiguousField) GetRoot() field { return d.Root }
+
+type productWithShadowedField struct {
+	Root field
+	baseData
+	ID field
+}
+
+func (d productWithShadowedField) GetRoot() field { return d.Root }
+
+type recursiveNode struct {
+	Root field
+	Next *recursiveNode
+}
+
+type recursiveData struct {
+	Root field
+	Node recursiveNode
+}
+
+func (d recursiveData) GetRoot() field { return d.Root }
+
+func TestFieldMap__Embedded(t *testing.T) {
+	t.Run("fields are promoted", func(t *testing.T) {
+		fm := New[field, productWithEmbedded](WithStructTags("json"))
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, field(1), p.Root)
+		assert.Equal(t, field(2), p.ID)
+		assert.Equal(t, field(3), p.Name)
+		assert.Equal(t, field(4), p.CreatedAt)
+		assert.Equal(t, field(5), p.Sku)
+
+		assert.Equal(t, []field{2, 3, 4, 5}, fm.ChildrenOf(p.Root))
+		assert.Equal(t, p.Root, fm.ParentOf(p.ID))
+
+		assert.Equal(t, "ID", fm.GetFullFieldName(p.ID))
+		assert.Equal(t, "createdAt", fm.GetFullStructTag("json", p.CreatedAt))
+	})
+
+	t.Run("promoted root", func(t *testing.T) {
+		fm := New[field, productWithPromotedRoot]()
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, field(1), p.Root)
+		assert.Equal(t, field(2), p.ID)
+		assert.Equal(t, field(3), p.Name)
+	})
+
+	t.Run("shadowed field", func(t *testing.T) {
+		fm := New[field, productWithShadowedField]()
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, field(2), p.Name)
+		assert.Equal(t, field(3), p.ID)
+		assert.Equal(t, field(0), p.baseData.ID)
+	})
+
+	t.Run("panics when ambiguous", func(t *testing.T) {
+		assert.PanicsWithValue(t, `ambiguous field "ID"`, func() {
+			New[field, productWithAmbiguousField]()
+		})
+	})
+
+	t.Run("panics with embedded pointer to unexported struct", func(t *testing.T) {
+		assert.PanicsWithValue(t, `embedded pointer to unexported struct "*fieldmap.baseData"`, func() {
+			New[field, productWithUnexportedPointer]()
+		})
+	})
+}
+
+func TestFieldMap__Pointer(t *testing.T) {
+	t.Run("pointer to struct", func(t *testing.T) {
+		fm := New[field, productWithPointer](WithStructTags("json"))
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, field(2), p.Sku)
+		assert.Equal(t, field(3), p.Seller.Root)
+		assert.Equal(t, field(4), p.Seller.ID)
+		assert.Equal(t, field(9), p.Seller.Attr.Name)
+		assert.Equal(t, field(10), p.Price)
+
+		assert.Equal(t, "Seller.Attr.Name", fm.GetFullFieldName(p.Seller.Attr.Name))
+		assert.Equal(t, "seller.id", fm.GetFullStructTag("json", p.Seller.ID))
+	})
+
+	t.Run("panics when recursive", func(t *testing.T) {
+		assert.PanicsWithValue(t, `recursive struct type for field "Node.Next"`, func() {
+			New[field, recursiveData]()
+		})
+	})
+}
@@ -0,0 +1,229 @@
+package fieldmap
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Tables is the precomputed content of a FieldMap, for constructing it with NewFromTables
+// without traversing the mapping struct by reflection. Usually generated by fieldmap-gen -tables.
+// Slices are indexed by ordinal - 1, keys registered with RegisterKey are not included.
+type Tables[F Field, T MapType[F]] struct {
+	// Mapping is the mapping struct with all fields already set
+	Mapping T
+
+	StructTags []string
+
+	// Names of fields, empty for the root
+	Names []string
+	// Parents of fields, zero for the root
+	Parents []F
+	// Tags contains the values of each struct tag of fields
+	Tags map[string][]string
+
+	// Attributes is nil if no field has attributes
+	Attributes []Attribute
+	// StableIDs is nil if no field has stable ids
+	StableIDs []uint32
+
+	// MapNodes contains the roots of map nodes, the Any field of a map node is right after its root
+	MapNodes []F
+}
+
+// NewFromTables creates a FieldMap from precomputed tables, panics if the tables are inconsistent.
+// The struct tags of the tables replace the option WithStructTags.
+func NewFromTables[F Field, T MapType[F]](tables Tables[F, T], options ...Option) *FieldMap[F, T] {
+	f := newFieldMap[F, T](options)
+	f.options.structTags = tables.StructTags
+
+	n := len(tables.Names)
+	if n == 0 {
+		panic("invalid tables: missing root field")
+	}
+	if len(tables.Parents) != n {
+		panic(fmt.Sprintf("invalid tables: %d parents for %d fields", len(tables.Parents), n))
+	}
+	for _, tag := range tables.StructTags {
+		if len(tables.Tags[tag]) != n {
+			panic(fmt.Sprintf("invalid tables: %d values of struct tag %q for %d fields", len(tables.Tags[tag]), tag, n))
+		}
+	}
+	if tables.Attributes != nil && len(tables.Attributes) != n {
+		panic(fmt.Sprintf("invalid tables: %d attributes for %d fields", len(tables.Attributes), n))
+	}
+	if tables.StableIDs != nil && len(tables.StableIDs) != n {
+		panic(fmt.Sprintf("invalid tables: %d stable ids for %d fields", len(tables.StableIDs), n))
+	}
+
+	anyFields := map[F]bool{}
+	for _, root := range tables.MapNodes {
+		if int(root) < 1 || int(root) >= n || tables.Parents[root] != root {
+			panic(fmt.Sprintf("invalid tables: invalid map node %d", root))
+		}
+		anyFields[root+1] = true
+	}
+
+	for i := 0; i < n; i++ {
+		parent := tables.Parents[i]
+		if (i == 0) != (parent == 0) || int(parent) > i {
+			panic(fmt.Sprintf("invalid tables: invalid parent %d of field %d", parent, i+1))
+		}
+
+		structTags := map[string]string{}
+		for _, tag := range tables.StructTags {
+			structTags[tag] = tables.Tags[tag][i]
+		}
+
+		var attrs Attribute
+		if tables.Attributes != nil {
+			attrs = tables.Attributes[i]
+		}
+
+		field := f.appendField(parent, tables.Names[i], structTags, attrs)
+		if i == 0 {
+			f.structRoot = field
+			continue
+		}
+		if anyFields[field] {
+			f.mapNodes[parent] = &mapNodeData[F]{
+				anyField: field,
+				keys:     map[string]F{},
+			}
+			continue
+		}
+
+		var stableID uint32
+		if tables.StableIDs != nil {
+			stableID = tables.StableIDs[i]
+		}
+		f.mustSetStableID(field, stableID)
+	}
+
+	if tables.Mapping.GetRoot() != f.structRoot {
+		panic("invalid GetRoot implementation")
+	}
+	f.mapping = tables.Mapping
+
+	return f
+}
+
+// Tables returns the content of the FieldMap, without keys registered with RegisterKey
+func (f *FieldMap[F, T]) Tables() Tables[F, T] {
+	n := len(f.fields) - len(f.mapKeyOf)
+
+	tables := Tables[F, T]{
+		Mapping:    f.mapping,
+		StructTags: f.options.structTags,
+		Names:      make([]string, 0, n),
+		Parents:    make([]F, 0, n),
+	}
+
+	for _, tag := range f.options.structTags {
+		if tables.Tags == nil {
+			tables.Tags = map[string][]string{}
+		}
+		tables.Tags[tag] = append([]string(nil), f.structTags[tag][:n]...)
+	}
+
+	for _, field := range f.fields[:n] {
+		index := f.indexOf(field)
+		tables.Names = append(tables.Names, f.fieldNames[index])
+		tables.Parents = append(tables.Parents, f.parentList[index])
+
+		if f.attributes[index] != 0 && tables.Attributes == nil {
+			tables.Attributes = make([]Attribute, n)
+		}
+		if f.stableIDs[index] != 0 && tables.StableIDs == nil {
+			tables.StableIDs = make([]uint32, n)
+		}
+	}
+	if tables.Attributes != nil {
+		copy(tables.Attributes, f.attributes[:n])
+	}
+	if tables.StableIDs != nil {
+		copy(tables.StableIDs, f.stableIDs[:n])
+	}
+
+	for _, field := range f.fields[:n] {
+		if _, ok := f.mapNodes[field]; ok {
+			tables.MapNodes = append(tables.MapNodes, field)
+		}
+	}
+
+	return tables
+}
+
+// VerifyTables checks that the tables are the same as the FieldMap computed by New,
+// for testing generated tables
+func VerifyTables[F Field, T MapType[F]](tables Tables[F, T], options ...Option) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("fieldmap: %v", r)
+		}
+	}()
+
+	options = append(options, WithStructTags(tables.StructTags...))
+	expected := New[F, T](options...).Tables()
+
+	return compareTables(expected, tables)
+}
+
+func compareTables[F Field, T MapType[F]](expected Tables[F, T], actual Tables[F, T]) error {
+	mismatch := func(name string, expectedValue any, actualValue any) error {
+		return fmt.Errorf("fieldmap: tables mismatch %s: expected %v, actual %v", name, expectedValue, actualValue)
+	}
+
+	if !reflect.DeepEqual(expected.StructTags, actual.StructTags) {
+		return mismatch("struct tags", expected.StructTags, actual.StructTags)
+	}
+	n := len(expected.Names)
+	if len(actual.Names) != n || len(actual.Parents) != n {
+		return mismatch("number of fields", n, len(actual.Names))
+	}
+	for _, tag := range expected.StructTags {
+		if len(actual.Tags[tag]) != n {
+			return mismatch(fmt.Sprintf("number of values of struct tag %q", tag), n, len(actual.Tags[tag]))
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		field := fmt.Sprintf("of field %d", i+1)
+		if expected.Names[i] != actual.Names[i] {
+			return mismatch("name "+field, expected.Names[i], actual.Names[i])
+		}
+		if expected.Parents[i] != actual.Parents[i] {
+			return mismatch("parent "+field, expected.Parents[i], actual.Parents[i])
+		}
+		for _, tag := range expected.StructTags {
+			if expected.Tags[tag][i] != actual.Tags[tag][i] {
+				return mismatch(fmt.Sprintf("struct tag %q %s", tag, field), expected.Tags[tag][i], actual.Tags[tag][i])
+			}
+		}
+
+		expectedAttrs := valueAt(expected.Attributes, i)
+		if actualAttrs := valueAt(actual.Attributes, i); expectedAttrs != actualAttrs {
+			return mismatch("attributes "+field, expectedAttrs, actualAttrs)
+		}
+		expectedID := valueAt(expected.StableIDs, i)
+		if actualID := valueAt(actual.StableIDs, i); expectedID != actualID {
+			return mismatch("stable id "+field, expectedID, actualID)
+		}
+	}
+
+	if !reflect.DeepEqual(expected.MapNodes, actual.MapNodes) {
+		return mismatch("map nodes", expected.MapNodes, actual.MapNodes)
+	}
+	if !reflect.DeepEqual(expected.Mapping, actual.Mapping) {
+		return mismatch("mapping", expected.Mapping, actual.Mapping)
+	}
+	return nil
+}
+
+// valueAt returns the zero value for nil slices
+func valueAt[V any](values []V, i int) V {
+	var empty V
+	if i >= len(values) {
+		return empty
+	}
+	return values[i]
+}
@@ -0,0 +1,139 @@
+package fieldmap
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewFromTables(t *testing.T) {
+	t.Run("same as new", func(t *testing.T) {
+		expected := New[field, productData](WithStructTags("json"))
+
+		tables := expected.Tables()
+		assert.Equal(t, []string{"", "Sku", "Name", "Seller", "ID", "Name", "Logo", "Attr", "Code", "Name", "ImageURL"},
+			tables.Names)
+		assert.Equal(t, []field{0, 1, 1, 1, 4, 4, 4, 4, 8, 8, 1}, tables.Parents)
+		assert.Equal(t, map[string][]string{
+			"json": {"", "sku", "name", "seller", "id", "name", "logo", "attr", "code", "name", "imageUrl"},
+		}, tables.Tags)
+		assert.Equal(t, []Attribute(nil), tables.Attributes)
+		assert.Equal(t, []uint32(nil), tables.StableIDs)
+
+		fm := NewFromTables(tables)
+		assert.Equal(t, expected.GetMapping(), fm.GetMapping())
+		assert.Equal(t, tables, fm.Tables())
+		assert.Equal(t, expected.Fingerprint(), fm.Fingerprint())
+
+		m := fm.GetMapping()
+		assert.Equal(t, "Seller.Attr.Code", fm.GetFullFieldName(m.Seller.Attr.Code))
+		assert.Equal(t, "seller.attr.code", fm.GetFullStructTag("json", m.Seller.Attr.Code))
+		assert.Equal(t, []field{m.Seller.ID, m.Seller.Name, m.Seller.Logo, m.Seller.Attr.Root},
+			fm.ChildrenOf(m.Seller.Root))
+	})
+
+	t.Run("with map nodes, attributes and stable ids", func(t *testing.T) {
+		expected := New[field, catalogData](WithStructTags("json"))
+		expected.RegisterKey(expected.GetMapping().Attributes, "color")
+
+		tables := expected.Tables()
+		assert.Equal(t, []string{"", "Sku", "Attributes", "*", "Name"}, tables.Names)
+		assert.Equal(t, []field{3}, tables.MapNodes)
+
+		fm := NewFromTables(tables)
+		assert.Equal(t, expected.GetMapping(), fm.GetMapping())
+		color := fm.RegisterKey(fm.GetMapping().Attributes, "color")
+		assert.Equal(t, "attributes.color", fm.GetFullStructTag("json", color))
+
+		withIDs := New[field, productWithIDs](WithStableIDs())
+		fromTables := NewFromTables(withIDs.Tables(), WithStableIDs())
+		assert.Equal(t, withIDs.Tables(), fromTables.Tables())
+		assert.Equal(t, withIDs.Fingerprint(), fromTables.Fingerprint())
+
+		withAttrs := New[field, sellerWithAttrsData]()
+		assert.Equal(t, []Attribute{
+			0,
+			AttributeDeprecated,
+			AttributeImmutable | AttributeDeprecated,
+			AttributeRequired | AttributeDeprecated,
+		}, withAttrs.Tables().Attributes)
+		assert.Equal(t, withAttrs.Tables(), NewFromTables(withAttrs.Tables()).Tables())
+	})
+
+	t.Run("invalid tables", func(t *testing.T) {
+		tables := New[field, productData]().Tables()
+
+		assert.PanicsWithValue(t, "invalid tables: missing root field", func() {
+			NewFromTables(Tables[field, productData]{})
+		})
+
+		invalid := tables
+		invalid.Parents = invalid.Parents[:3]
+		assert.PanicsWithValue(t, "invalid tables: 3 parents for 11 fields", func() {
+			NewFromTables(invalid)
+		})
+
+		invalid = tables
+		invalid.StructTags = []string{"json"}
+		assert.PanicsWithValue(t, `invalid tables: 0 values of struct tag "json" for 11 fields`, func() {
+			NewFromTables(invalid)
+		})
+
+		invalid = tables
+		invalid.Parents = []field{0, 1, 1, 5, 4, 4, 4, 4, 8, 8, 1}
+		assert.PanicsWithValue(t, "invalid tables: invalid parent 5 of field 4", func() {
+			NewFromTables(invalid)
+		})
+
+		invalid = tables
+		invalid.MapNodes = []field{2}
+		assert.PanicsWithValue(t, "invalid tables: invalid map node 2", func() {
+			NewFromTables(invalid)
+		})
+
+		invalid = tables
+		invalid.Mapping.Root = 2
+		assert.PanicsWithValue(t, "invalid GetRoot implementation", func() {
+			NewFromTables(invalid)
+		})
+	})
+}
+
+func TestVerifyTables(t *testing.T) {
+	tables := New[field, productData](WithStructTags("json")).Tables()
+	assert.Equal(t, nil, VerifyTables(tables))
+
+	invalid := tables
+	invalid.Names = append([]string(nil), tables.Names...)
+	invalid.Names[2] = "Title"
+	assert.Equal(t,
+		errors.New(`fieldmap: tables mismatch name of field 3: expected Name, actual Title`),
+		VerifyTables(invalid))
+
+	invalid = tables
+	invalid.Tags = map[string][]string{"json": append([]string(nil), tables.Tags["json"]...)}
+	invalid.Tags["json"][1] = "SKU"
+	assert.Equal(t,
+		errors.New(`fieldmap: tables mismatch struct tag "json" of field 2: expected sku, actual SKU`),
+		VerifyTables(invalid))
+
+	invalid = tables
+	invalid.Attributes = make([]Attribute, len(tables.Names))
+	invalid.Attributes[1] = AttributeReadonly
+	assert.Equal(t,
+		errors.New(`fieldmap: tables mismatch attributes of field 2: expected , actual readonly`),
+		VerifyTables(invalid))
+
+	invalid = tables
+	invalid.Mapping.Sku = 3
+	assert.Equal(t,
+		`fieldmap: tables mismatch mapping: expected {1 2 3 {4 5 6 7 {8 9 10}} 11}, `+
+			`actual {1 3 3 {4 5 6 7 {8 9 10}} 11}`,
+		VerifyTables(invalid).Error())
+
+	invalid = tables
+	invalid.StructTags = []string{"db"}
+	assert.Equal(t,
+		errors.New(`fieldmap: missing struct tag "db" for field "Sku"`),
+		VerifyTables(invalid))
+}
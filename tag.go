@@ -0,0 +1,82 @@
+package fieldmap
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TagName is the struct tag for specifying options of a field in mapping structs
+const TagName = "fieldmap"
+
+const (
+	tagOptionSkip = "-"
+	tagOptionLeaf = "leaf"
+	tagOptionRoot = "root"
+
+	tagOptionStableIDPrefix = "id="
+)
+
+type tagOptions struct {
+	skip bool
+	leaf bool
+	root bool
+
+	attributes Attribute
+	stableID   uint32
+}
+
+func parseTagOptions(tag string) (tagOptions, error) {
+	var opts tagOptions
+	if len(tag) == 0 {
+		return opts, nil
+	}
+
+	if tag == tagOptionSkip {
+		opts.skip = true
+		return opts, nil
+	}
+
+	for _, option := range strings.Split(tag, ",") {
+		option = strings.TrimSpace(option)
+		switch option {
+		case tagOptionLeaf:
+			opts.leaf = true
+		case tagOptionRoot:
+			opts.root = true
+		default:
+			if strings.HasPrefix(option, tagOptionStableIDPrefix) {
+				id, err := parseStableID(strings.TrimPrefix(option, tagOptionStableIDPrefix))
+				if err != nil {
+					return opts, err
+				}
+				opts.stableID = id
+				continue
+			}
+
+			attr, ok := parseAttribute(option)
+			if !ok {
+				return opts, fmt.Errorf("unknown option %q", option)
+			}
+			opts.attributes |= attr
+		}
+	}
+	return opts, nil
+}
+
+func getTagOptions(fieldType reflect.StructField, fullFieldName string) tagOptions {
+	opts, err := parseTagOptions(fieldType.Tag.Get(TagName))
+	if err != nil {
+		panic(fmt.Sprintf("invalid %s tag for field %q: %v", TagName, fullFieldName, err))
+	}
+	return opts
+}
+
+func parseStableID(s string) (uint32, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid stable id %q", s)
+	}
+	return uint32(id), nil
+}
@@ -0,0 +1,137 @@
+package fieldmap
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type money struct {
+	Amount   int64
+	Currency string
+}
+
+type productWithHelperFields struct {
+	Root field
+
+	Sku   field  `json:"sku"`
+	Price field  `json:"price" fieldmap:"leaf"`
+	Note  string `fieldmap:"-"`
+	Name  field  `json:"name"`
+
+	cache map[string]int
+}
+
+func (d productWithHelperFields) GetRoot() field { return d.Root }
+
+type productWithLeafField struct {
+	Root   field
+	Seller sellerData `fieldmap:"leaf"`
+	Sku    field
+}
+
+func (d productWithLeafField) GetRoot() field { return d.Root }
+
+type productWithValueObject struct {
+	Root  field
+	Price money `json:"price" fieldmap:"leaf"`
+	Sku   field `json:"sku"`
+}
+
+func (d productWithValueObject) GetRoot() field { return d.Root }
+
+type productWithUnknownTagOption struct {
+	Root field
+	Sku  field `fieldmap:"leaf,other"`
+}
+
+func (d productWithUnknownTagOption) GetRoot() field { return d.Root }
+
+func TestParseTagOptions(t *testing.T) {
+	opts, err := parseTagOptions("")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, tagOptions{}, opts)
+
+	opts, err = parseTagOptions("-")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, tagOptions{skip: true}, opts)
+
+	opts, err = parseTagOptions("leaf")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, tagOptions{leaf: true}, opts)
+
+	opts, err = parseTagOptions("leaf, readonly,deprecated")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, tagOptions{leaf: true, attributes: AttributeReadonly | AttributeDeprecated}, opts)
+
+	_, err = parseTagOptions("leaf,other")
+	assert.Equal(t, errors.New(`unknown option "other"`), err)
+}
+
+func TestFieldMap__TagOptions(t *testing.T) {
+	t.Run("skip and leaf fields", func(t *testing.T) {
+		fm := New[field, productWithHelperFields](WithStructTags("json"))
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, field(1), p.Root)
+		assert.Equal(t, field(2), p.Sku)
+		assert.Equal(t, field(3), p.Price)
+		assert.Equal(t, field(4), p.Name)
+
+		assert.Equal(t, false, fm.IsStruct(p.Price))
+		assert.Equal(t, "price", fm.GetFullStructTag("json", p.Price))
+		assert.Equal(t, []field{2, 3, 4}, fm.ChildrenOf(p.Root))
+
+		_, ok := fm.FindByFullFieldName("Note")
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("struct as leaf field", func(t *testing.T) {
+		fm := New[field, productWithLeafField]()
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, field(2), p.Seller.Root)
+		assert.Equal(t, field(0), p.Seller.ID)
+		assert.Equal(t, field(3), p.Sku)
+
+		assert.Equal(t, false, fm.IsStruct(p.Seller.Root))
+		assert.Equal(t, "Seller", fm.GetFullFieldName(p.Seller.Root))
+	})
+
+	t.Run("value object as leaf field", func(t *testing.T) {
+		fm := New[field, productWithValueObject](WithStructTags("json"))
+
+		p := fm.GetMapping()
+
+		assert.Equal(t, money{}, p.Price)
+		assert.Equal(t, field(3), p.Sku)
+
+		price, ok := fm.FindByFullFieldName("Price")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, field(2), price)
+		assert.Equal(t, false, fm.IsStruct(price))
+		assert.Equal(t, "price", fm.GetFullStructTag("json", price))
+	})
+
+	t.Run("find by full field name", func(t *testing.T) {
+		fm := New[field, productData]()
+
+		p := fm.GetMapping()
+
+		code, ok := fm.FindByFullFieldName("Seller.Attr.Code")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, p.Seller.Attr.Code, code)
+
+		seller, ok := fm.FindByFullFieldName("Seller")
+		assert.Equal(t, true, ok)
+		assert.Equal(t, p.Seller.Root, seller)
+	})
+
+	t.Run("panics with unknown option", func(t *testing.T) {
+		assert.PanicsWithValue(t, `invalid fieldmap tag for field "Sku": unknown option "other"`, func() {
+			New[field, productWithUnknownTagOption]()
+		})
+	})
+}